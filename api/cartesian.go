@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// CartesianCommand is a struct for the command moving the gripper in Joint mode with the kinematics of leubot
+// X, Y and Z are in millimeters and Pitch in degrees, the omitted ones keep their current values
+// Queue waits for the motion commands in line
+type CartesianCommand struct {
+	Token string   `json:"token"`
+	X     *float64 `json:"x,omitempty"`
+	Y     *float64 `json:"y,omitempty"`
+	Z     *float64 `json:"z,omitempty"`
+	Pitch *float64 `json:"pitch,omitempty"`
+	Speed *int     `json:"speed,omitempty"`
+	Queue bool     `json:"queue,omitempty"`
+}
+
+// CartesianState is the position of the gripper computed from the commanded pose
+type CartesianState struct {
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Z     float64 `json:"z"`
+	Pitch float64 `json:"pitch"`
+}
+
+// GetCartesian processes the request for the position of the gripper
+func GetCartesian(w http.ResponseWriter, r *http.Request) {
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type: TypeGetCartesian,
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeCurrentPose: // respond with the position
+		log.Println("[HandlerChannel] CurrentCartesian")
+		writeJSON(w, msg.Value[0])
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
+
+// PutCartesian processes the request for moving the gripper
+func PutCartesian(w http.ResponseWriter, r *http.Request) {
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var cartesianCommand CartesianCommand
+	err := decoder.Decode(&cartesianCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePutCartesian,
+		Value: []interface{}{cartesianCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // the requested action is performed
+		log.Printf("[HandlerChannel] PutCartesian: %v", msg.Value[1])
+		writeAccepted(w, msg)
+	case TypeInvalidCommand: // unreachable or out of the joint limits
+		log.Println("[HandlerChannel] InvalidCommand")
+		writeError(w, msg, http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", cartesianCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"path"
+	"time"
+)
+
+// The statuses of a motion command
+const (
+	CommandQueued    = "queued"
+	CommandExecuting = "executing"
+	CommandCompleted = "completed"
+	CommandCanceled  = "canceled"
+	CommandFailed    = "failed"
+)
+
+// CommandState is the progress of a motion command with the Pose in ticks it commands in Joint mode,
+// or the Position in the IK modes, and the pose Measured by the servos once completed if the backend reads them
+// Estimated is when the arm is expected to be there, from the delta of the move
+type CommandState struct {
+	ID        string         `json:"id"`
+	Status    string         `json:"status"`
+	Error     string         `json:"error,omitempty"`
+	Mode      string         `json:"mode"`
+	Created   time.Time      `json:"created"`
+	Started   *time.Time     `json:"started,omitempty"`
+	Estimated *time.Time     `json:"estimated,omitempty"`
+	Finished  *time.Time     `json:"finished,omitempty"`
+	Pose      map[string]int `json:"pose,omitempty"`
+	Position  *PositionState `json:"position,omitempty"`
+	Measured  map[string]int `json:"measured,omitempty"`
+}
+
+// writeAccepted responds with the CommandState of the accepted motion carried by the msg, and its Location
+func writeAccepted(w http.ResponseWriter, msg HandlerMessage) {
+	commandState, ok := msg.Value[0].(CommandState)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	w.Header().Set("Location", APIProto+APIHost+APIBaseURL+"/commands/"+commandState.ID)
+	js, _ := json.Marshal(commandState)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusAccepted) // 202
+	w.Write(js)
+}
+
+// GetCommand processes the request for the progress of a motion command
+func GetCommand(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypeGetCommand,
+		Value: []interface{}{id},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeCurrentCommand: // respond with the progress
+		writeJSON(w, msg.Value[0])
+	case TypeCommandNotFound: // unknown or forgotten
+		log.Printf("[HandlerChannel] CommandNotFound: %v", id)
+		w.WriteHeader(http.StatusNotFound) // 404
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
+
+// DeleteCommand processes the request to cancel a queued command and the ones queued after it,
+// by its owner or with the master token
+func DeleteCommand(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var token Token
+	err := decoder.Decode(&token)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypeDeleteCommand,
+		Value: []interface{}{id, token.Token},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // the command is canceled
+		log.Printf("[HandlerChannel] DeleteCommand: %v", id)
+		writeJSON(w, msg.Value[0])
+	case TypeInvalidToken: // not the owner
+		log.Printf("[HandlerChannel] InvalidToken: %v", token.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeCommandNotFound: // unknown or forgotten
+		log.Printf("[HandlerChannel] CommandNotFound: %v", id)
+		w.WriteHeader(http.StatusNotFound) // 404
+	case TypeCommandNotQueued: // already sent to the arm
+		log.Printf("[HandlerChannel] CommandNotQueued: %v", id)
+		writeError(w, msg, http.StatusConflict) // 409
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
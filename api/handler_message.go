@@ -1,5 +1,10 @@
 package api
 
+import (
+	"log"
+	"net/http"
+)
+
 // HandlerMessageType is the type for HandlerMessage
 type HandlerMessageType int
 
@@ -44,10 +49,190 @@ const (
 	TypeInvalidCommand
 	// TypeSomethingWentWrong says it didn't go well
 	TypeSomethingWentWrong
+	// TypeArmNotResponding says the arm did not confirm the instruction
+	TypeArmNotResponding
+	// TypeArmError says the arm replied with an error
+	TypeArmError
+	// TypeRobotDisconnected says the link to the robot is down
+	TypeRobotDisconnected
+	// TypePutMode is to change the mode of Leubot
+	TypePutMode
+	// TypePutPosition is to move the end-effector in the IK modes
+	TypePutPosition
+	// TypeWrongMode says the command is not available in the current mode
+	TypeWrongMode
+	// TypeGetTelemetry is to get the state of the servos
+	TypeGetTelemetry
+	// TypeTelemetry has the state of the servos
+	TypeTelemetry
+	// TypeNotSupported says the backend of the robot cannot do it
+	TypeNotSupported
+	// TypePutStop is to stop the robot immediately
+	TypePutStop
+	// TypePutRearm is to resume the motion after a stop
+	TypePutRearm
+	// TypeStopped says the robot is stopped until re-armed
+	TypeStopped
+	// TypeNotStopped says the robot is not stopped
+	TypeNotStopped
+	// TypePutPose is to move the joints at once
+	TypePutPose
+	// TypeGetPose is to get the current pose
+	TypeGetPose
+	// TypeCurrentPose has the current pose
+	TypeCurrentPose
+	// TypePatchJoint is to move a joint relatively
+	TypePatchJoint
+	// TypeGetCartesian is to get the position of the gripper
+	TypeGetCartesian
+	// TypePutCartesian is to move the gripper with the kinematics of leubot
+	TypePutCartesian
+	// TypePostTrajectory is to start a trajectory
+	TypePostTrajectory
+	// TypeTrajectoryStarted says the trajectory is started
+	TypeTrajectoryStarted
+	// TypeGetTrajectory is to get the progress of a trajectory
+	TypeGetTrajectory
+	// TypeCurrentTrajectory has the progress of the trajectory
+	TypeCurrentTrajectory
+	// TypeDeleteTrajectory is to cancel a trajectory
+	TypeDeleteTrajectory
+	// TypeTrajectoryNotFound says no such trajectory exists
+	TypeTrajectoryNotFound
+	// TypeTrajectoryFinished says the trajectory is not running anymore
+	TypeTrajectoryFinished
+	// TypeGetPoses is to get the named poses
+	TypeGetPoses
+	// TypeNamedPoses has the named poses
+	TypeNamedPoses
+	// TypePutNamedPose is to save a named pose
+	TypePutNamedPose
+	// TypePostNamedPose is to move to a named pose
+	TypePostNamedPose
+	// TypePoseNotFound says no such named pose exists
+	TypePoseNotFound
+	// TypePoseReadOnly says the named pose is defined by the admins
+	TypePoseReadOnly
+	// TypePostRecording is to start recording the moves
+	TypePostRecording
+	// TypeRecordingStarted says the recording is started
+	TypeRecordingStarted
+	// TypeRecordingActive says a recording is already running
+	TypeRecordingActive
+	// TypeStopRecording is to stop and save the active recording
+	TypeStopRecording
+	// TypeGetRecordings is to get the recordings
+	TypeGetRecordings
+	// TypeRecordings has the recordings
+	TypeRecordings
+	// TypeGetRecording is to get a recording with its frames
+	TypeGetRecording
+	// TypeCurrentRecording has the recording with its frames
+	TypeCurrentRecording
+	// TypePatchRecording is to rename a recording
+	TypePatchRecording
+	// TypePlayRecording is to replay a recording
+	TypePlayRecording
+	// TypeRecordingNotFound says no such recording exists
+	TypeRecordingNotFound
+	// TypePostProgram is to run a program
+	TypePostProgram
+	// TypeProgramStarted says the program is started
+	TypeProgramStarted
+	// TypeProgramRunning says another program is running
+	TypeProgramRunning
+	// TypeGetProgram is to get the progress of a program
+	TypeGetProgram
+	// TypeCurrentProgram has the progress of the program
+	TypeCurrentProgram
+	// TypeDeleteProgram is to cancel a program
+	TypeDeleteProgram
+	// TypeProgramNotFound says no such program exists
+	TypeProgramNotFound
+	// TypeProgramFinished says the program is not running anymore
+	TypeProgramFinished
+	// TypeUnsafePose says the pose takes the gripper out of the safety envelope
+	TypeUnsafePose
+	// TypeGetLimits is to get the limits of the joints and the home pose
+	TypeGetLimits
+	// TypeLimits has the limits of the joints and the home pose
+	TypeLimits
+	// TypeGetCommand is to get the progress of a motion command
+	TypeGetCommand
+	// TypeCurrentCommand has the progress of a motion command
+	TypeCurrentCommand
+	// TypeDeleteCommand is to cancel a queued motion command
+	TypeDeleteCommand
+	// TypeCommandNotFound says there is no such motion command
+	TypeCommandNotFound
+	// TypeCommandNotQueued says the motion command was already sent to the arm
+	TypeCommandNotQueued
+	// TypeCommandsQueued says the motion commands in line hold the command back
+	TypeCommandsQueued
 )
 
+// IsRobotCommand reports if the message type commands the robot
+func (t HandlerMessageType) IsRobotCommand() bool {
+	switch t {
+	case TypePutBase, TypePutShoulder, TypePutElbow, TypePutWristAngle, TypePutWristRotation, TypePutGripper, TypePutPose, TypePatchJoint, TypePutCartesian, TypePostTrajectory, TypePostNamedPose, TypePlayRecording, TypePostProgram, TypePutReset, TypePutMode, TypePutPosition:
+		return true
+	}
+	return false
+}
+
+// IsJointCommand reports if the message type commands a joint in Joint mode
+func (t HandlerMessageType) IsJointCommand() bool {
+	switch t {
+	case TypePutBase, TypePutShoulder, TypePutElbow, TypePutWristAngle, TypePutWristRotation, TypePutGripper, TypePutPose, TypePatchJoint, TypePutCartesian, TypePostTrajectory, TypePostNamedPose, TypePlayRecording:
+		return true
+	}
+	return false
+}
+
 // HandlerMessage contains the payload for the command messages
 type HandlerMessage struct {
 	Type  HandlerMessageType
 	Value []interface{}
+	// Program is the ID of the program sending the command, empty for the requests of the API
+	Program string
+}
+
+// requestUnit returns the unit given in the body, or in ?units= if omitted
+func requestUnit(r *http.Request, unit string) string {
+	if unit != "" {
+		return unit
+	}
+	return r.URL.Query().Get("units")
+}
+
+// writeRobotError responds to a robot command rejected by the controller for a reason shared by all of them,
+// or with 500 if the msg is not one
+func writeRobotError(w http.ResponseWriter, msg HandlerMessage) {
+	switch msg.Type {
+	case TypeCommandsQueued: // held back by the queued commands
+		log.Println("[HandlerChannel] CommandsQueued")
+		writeError(w, msg, http.StatusConflict) // 409
+	case TypeUnsafePose: // out of the safety envelope
+		log.Println("[HandlerChannel] UnsafePose")
+		writeError(w, msg, http.StatusUnprocessableEntity) // 422
+	case TypeStopped: // the robot is stopped until re-armed
+		log.Println("[HandlerChannel] Stopped")
+		w.WriteHeader(http.StatusLocked) // 423
+	case TypeRobotDisconnected: // the link to the robot is down
+		log.Println("[HandlerChannel] RobotDisconnected")
+		w.WriteHeader(http.StatusServiceUnavailable) // 503
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
+
+// writeError responds with the status and the error message carried by the msg, if any
+func writeError(w http.ResponseWriter, msg HandlerMessage, status int) {
+	if len(msg.Value) > 0 {
+		if s, ok := msg.Value[0].(string); ok {
+			http.Error(w, s, status)
+			return
+		}
+	}
+	w.WriteHeader(status)
 }
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"log"
+	"net/http"
+)
+
+// JointLimit is the range of a joint in ticks on this arm with its default,
+// within the range of the firmware
+type JointLimit struct {
+	Min         int `json:"min"`
+	Max         int `json:"max"`
+	Default     int `json:"default"`
+	FirmwareMin int `json:"firmwareMin"`
+	FirmwareMax int `json:"firmwareMax"`
+}
+
+// Limits is the JointLimit of each joint by its name, and the Home pose in ticks the arm goes to on reset
+type Limits struct {
+	Joints map[string]JointLimit `json:"joints"`
+	Home   map[string]int        `json:"home"`
+}
+
+// GetLimits processes the request for the limits of the joints and the home pose
+func GetLimits(w http.ResponseWriter, r *http.Request) {
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type: TypeGetLimits,
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok || msg.Type != TypeLimits {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	log.Println("[HandlerChannel] Limits")
+	writeJSON(w, msg.Value[0])
+}
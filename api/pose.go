@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// PoseCommand is a struct for the command moving the joints at once in Joint mode
+// The omitted joints keep their current values and the omitted Speed falls back on the speed of the user
+// The values are in the Unit, ticks if omitted, and Queue waits for the motion commands in line
+type PoseCommand struct {
+	Token         string   `json:"token"`
+	Base          *float64 `json:"base,omitempty"`
+	Shoulder      *float64 `json:"shoulder,omitempty"`
+	Elbow         *float64 `json:"elbow,omitempty"`
+	WristAngle    *float64 `json:"wristAngle,omitempty"`
+	WristRotation *float64 `json:"wristRotation,omitempty"`
+	Gripper       *float64 `json:"gripper,omitempty"`
+	Unit          string   `json:"unit,omitempty"`
+	Speed         *int     `json:"speed,omitempty"`
+	Queue         bool     `json:"queue,omitempty"`
+}
+
+// PutPose processes the request for the whole pose
+func PutPose(w http.ResponseWriter, r *http.Request) {
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var poseCommand PoseCommand
+	err := decoder.Decode(&poseCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	poseCommand.Unit = requestUnit(r, poseCommand.Unit)
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePutPose,
+		Value: []interface{}{poseCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // the requested action is performed
+		log.Println("[HandlerChannel] PutPose")
+		writeAccepted(w, msg)
+	case TypeInvalidCommand: // the invalid value provided
+		log.Println("[HandlerChannel] InvalidCommand")
+		writeError(w, msg, http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", poseCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
+	}
+}
+
+// JointState is the commanded value of a joint in ticks, degrees and radians with its legal range and default in ticks
+type JointState struct {
+	Value   int     `json:"value"`
+	Degrees float64 `json:"degrees"`
+	Radians float64 `json:"radians"`
+	Min     int     `json:"min"`
+	Max     int     `json:"max"`
+	Default int     `json:"default"`
+}
+
+// PositionState is the commanded position of the end-effector in the IK modes
+type PositionState struct {
+	X             int    `json:"x"`
+	Y             int    `json:"y"`
+	Z             int    `json:"z"`
+	Base          uint16 `json:"base"`
+	WristAngle    int    `json:"wristAngle"`
+	WristRotation uint16 `json:"wristRotation"`
+	Gripper       uint16 `json:"gripper"`
+}
+
+// PoseState is the commanded pose of the robot
+type PoseState struct {
+	Mode     string                `json:"mode"`
+	Asleep   bool                  `json:"asleep"`
+	Stopped  bool                  `json:"stopped"`
+	Joints   map[string]JointState `json:"joints"`
+	Position *PositionState        `json:"position,omitempty"`
+}
+
+// jointPaths maps the paths of the joint endpoints to the names of the joints
+var jointPaths = map[string]string{
+	"/base":           "base",
+	"/shoulder":       "shoulder",
+	"/elbow":          "elbow",
+	"/wrist/angle":    "wristAngle",
+	"/wrist/rotation": "wristRotation",
+	"/gripper":        "gripper",
+}
+
+// getPoseState asks the controller for the PoseState
+func getPoseState() (*PoseState, bool) {
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type: TypeGetPose,
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status and the result
+	if !ok || msg.Type != TypeCurrentPose {
+		return nil, false
+	}
+	poseState, ok := msg.Value[0].(PoseState)
+	return &poseState, ok
+}
+
+// writeJSON responds with v in JSON
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK) // 200
+	w.Write(js)
+}
+
+// GetPose processes the request for the current pose
+func GetPose(w http.ResponseWriter, r *http.Request) {
+	poseState, ok := getPoseState()
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	log.Printf("[HandlerChannel] CurrentPose: %v", poseState.Mode)
+	writeJSON(w, poseState)
+}
+
+// GetJoint processes the request for the current value of a joint
+func GetJoint(w http.ResponseWriter, r *http.Request) {
+	joint, ok := jointPaths[strings.TrimPrefix(r.URL.Path, APIBaseURL)]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound) // 404
+		return
+	}
+	poseState, ok := getPoseState()
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	log.Printf("[HandlerChannel] CurrentPose: %v", joint)
+	writeJSON(w, struct {
+		Joint string `json:"joint"`
+		JointState
+		Mode    string `json:"mode"`
+		Asleep  bool   `json:"asleep"`
+		Stopped bool   `json:"stopped"`
+	}{
+		Joint:      joint,
+		JointState: poseState.Joints[joint],
+		Mode:       poseState.Mode,
+		Asleep:     poseState.Asleep,
+		Stopped:    poseState.Stopped,
+	})
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"path"
+)
+
+// NamedPoseCommand is a struct for the command saving a named pose, the current pose if no joint is given
+// The omitted joints keep their current values, in the Unit, ticks if omitted
+// Only the master token can save a ReadOnly pose, or over one
+type NamedPoseCommand struct {
+	Token         string   `json:"token"`
+	Base          *float64 `json:"base,omitempty"`
+	Shoulder      *float64 `json:"shoulder,omitempty"`
+	Elbow         *float64 `json:"elbow,omitempty"`
+	WristAngle    *float64 `json:"wristAngle,omitempty"`
+	WristRotation *float64 `json:"wristRotation,omitempty"`
+	Gripper       *float64 `json:"gripper,omitempty"`
+	Unit          string   `json:"unit,omitempty"`
+	ReadOnly      bool     `json:"readOnly,omitempty"`
+}
+
+// GoCommand is a struct for the command moving to a named pose
+// The omitted Speed falls back on the speed of the user, and Queue waits for the motion commands in line
+type GoCommand struct {
+	Token string `json:"token"`
+	Speed *int   `json:"speed,omitempty"`
+	Queue bool   `json:"queue,omitempty"`
+}
+
+// GetPoses processes the request for the named poses
+func GetPoses(w http.ResponseWriter, r *http.Request) {
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type: TypeGetPoses,
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok || msg.Type != TypeNamedPoses {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	log.Println("[HandlerChannel] NamedPoses")
+	writeJSON(w, msg.Value[0])
+}
+
+// PutNamedPose processes the request to save a named pose
+func PutNamedPose(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var namedPoseCommand NamedPoseCommand
+	err := decoder.Decode(&namedPoseCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	namedPoseCommand.Unit = requestUnit(r, namedPoseCommand.Unit)
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePutNamedPose,
+		Value: []interface{}{name, namedPoseCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // respond with the saved pose
+		log.Printf("[HandlerChannel] PutNamedPose: %v", name)
+		writeJSON(w, msg.Value[0])
+	case TypeInvalidCommand: // invalid name or values
+		log.Printf("[HandlerChannel] InvalidCommand: %v", name)
+		writeError(w, msg, http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", namedPoseCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypePoseReadOnly: // defined by the admins
+		log.Printf("[HandlerChannel] PoseReadOnly: %v", name)
+		w.WriteHeader(http.StatusForbidden) // 403
+	case TypeWrongMode: // the current pose is stale outside of Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	case TypeUnsafePose: // out of the safety envelope
+		log.Printf("[HandlerChannel] UnsafePose: %v", name)
+		writeError(w, msg, http.StatusUnprocessableEntity) // 422
+	default: // something went wrong, such as writing the file
+		writeError(w, msg, http.StatusInternalServerError) // 500
+	}
+}
+
+// PostNamedPose processes the request to move to a named pose
+func PostNamedPose(w http.ResponseWriter, r *http.Request) {
+	// the name is before /go
+	name := path.Base(path.Dir(r.URL.Path))
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var goCommand GoCommand
+	err := decoder.Decode(&goCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePostNamedPose,
+		Value: []interface{}{name, goCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // the requested action is performed
+		log.Printf("[HandlerChannel] PostNamedPose: %v", name)
+		writeAccepted(w, msg)
+	case TypeInvalidCommand: // invalid speed
+		log.Printf("[HandlerChannel] InvalidCommand: %v", name)
+		writeError(w, msg, http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", goCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypePoseNotFound: // no such pose
+		log.Printf("[HandlerChannel] PoseNotFound: %v", name)
+		w.WriteHeader(http.StatusNotFound) // 404
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// ModeCommand is a struct for the command to change the mode
+type ModeCommand struct {
+	Token string `json:"token"`
+	Mode  string `json:"mode"`
+}
+
+// PositionCommand is a struct for the command in the IK modes
+// The omitted fields keep their current values and the omitted Speed falls back on the speed of the user
+// Queue waits for the motion commands in line
+type PositionCommand struct {
+	Token         string  `json:"token"`
+	X             *int    `json:"x,omitempty"`
+	Y             *int    `json:"y,omitempty"`
+	Z             *int    `json:"z,omitempty"`
+	Base          *uint16 `json:"base,omitempty"`
+	WristAngle    *int    `json:"wristAngle,omitempty"`
+	WristRotation *uint16 `json:"wristRotation,omitempty"`
+	Gripper       *uint16 `json:"gripper,omitempty"`
+	Speed         *int    `json:"speed,omitempty"`
+	Queue         bool    `json:"queue,omitempty"`
+}
+
+// PutMode processes the request to change the mode
+func PutMode(w http.ResponseWriter, r *http.Request) {
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var modeCommand ModeCommand
+	err := decoder.Decode(&modeCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePutMode,
+		Value: []interface{}{modeCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // the requested action is performed
+		log.Printf("[HandlerChannel] PutMode: %v", modeCommand.Mode)
+		w.WriteHeader(http.StatusAccepted) // 202
+	case TypeInvalidCommand: // the invalid mode provided
+		log.Printf("[HandlerChannel] InvalidCommand: %v", modeCommand.Mode)
+		w.WriteHeader(http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", modeCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeArmNotResponding: // the mode change was not confirmed
+		log.Println("[HandlerChannel] ArmNotResponding")
+		w.WriteHeader(http.StatusGatewayTimeout) // 504
+	case TypeArmError: // the arm replied with an error
+		log.Printf("[HandlerChannel] ArmError: %v", msg.Value[0])
+		w.WriteHeader(http.StatusBadGateway) // 502
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
+	}
+}
+
+// PutPosition processes the request for the position in the IK modes
+func PutPosition(w http.ResponseWriter, r *http.Request) {
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var positionCommand PositionCommand
+	err := decoder.Decode(&positionCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePutPosition,
+		Value: []interface{}{positionCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // the requested action is performed
+		log.Println("[HandlerChannel] PutPosition")
+		writeAccepted(w, msg)
+	case TypeInvalidCommand: // the invalid value provided
+		log.Println("[HandlerChannel] InvalidCommand")
+		writeError(w, msg, http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", positionCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeWrongMode: // not in one of the IK modes
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
+	}
+}
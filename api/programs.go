@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"path"
+)
+
+// The statuses of a program
+const (
+	ProgramRunning  = "running"
+	ProgramDone     = "done"
+	ProgramCanceled = "canceled"
+	ProgramStopped  = "stopped"
+	ProgramFailed   = "failed"
+)
+
+// ProgramCommand is a struct for the command running a motion program in Starlark
+type ProgramCommand struct {
+	Token  string `json:"token"`
+	Source string `json:"source"`
+}
+
+// ProgramState is the progress of a program with its printed Output, the last lines of it,
+// Elapsed in milliseconds and the Steps of computation once it is over
+type ProgramState struct {
+	ID      string   `json:"id"`
+	Status  string   `json:"status"`
+	Output  []string `json:"output"`
+	Error   string   `json:"error,omitempty"`
+	Elapsed int      `json:"elapsed"`
+	Steps   uint64   `json:"steps"`
+}
+
+// PostProgram processes the request to run a program
+func PostProgram(w http.ResponseWriter, r *http.Request) {
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var programCommand ProgramCommand
+	err := decoder.Decode(&programCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePostProgram,
+		Value: []interface{}{programCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeProgramStarted: // respond with the started program
+		programState, ok := msg.Value[0].(ProgramState)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError) // 500
+			return
+		}
+		log.Printf("[HandlerChannel] ProgramStarted: %v", programState.ID)
+		w.Header().Set("Location", APIProto+APIHost+APIBaseURL+"/programs/"+programState.ID)
+		js, _ := json.Marshal(programState)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusCreated) // 201
+		w.Write(js)
+	case TypeInvalidCommand: // the program does not compile
+		log.Println("[HandlerChannel] InvalidCommand")
+		writeError(w, msg, http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", programCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeProgramRunning: // another program is running
+		log.Println("[HandlerChannel] ProgramRunning")
+		writeError(w, msg, http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
+	}
+}
+
+// GetProgram processes the request for the progress of a program
+func GetProgram(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypeGetProgram,
+		Value: []interface{}{id},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeCurrentProgram: // respond with the progress
+		writeJSON(w, msg.Value[0])
+	case TypeProgramNotFound: // not the latest program
+		log.Printf("[HandlerChannel] ProgramNotFound: %v", id)
+		w.WriteHeader(http.StatusNotFound) // 404
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
+
+// DeleteProgram processes the request to cancel a program, by its owner or with the master token
+func DeleteProgram(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var token Token
+	err := decoder.Decode(&token)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypeDeleteProgram,
+		Value: []interface{}{id, token.Token},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // the program is canceled
+		log.Printf("[HandlerChannel] DeleteProgram: %v", id)
+		writeJSON(w, msg.Value[0])
+	case TypeInvalidToken: // not the owner
+		log.Printf("[HandlerChannel] InvalidToken: %v", token.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeProgramNotFound: // not the latest program
+		log.Printf("[HandlerChannel] ProgramNotFound: %v", id)
+		w.WriteHeader(http.StatusNotFound) // 404
+	case TypeProgramFinished: // nothing to cancel
+		log.Printf("[HandlerChannel] ProgramFinished: %v", id)
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
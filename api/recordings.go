@@ -0,0 +1,255 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"path"
+)
+
+// RecordingCommand is a struct for the command starting or renaming a recording
+type RecordingCommand struct {
+	Token string `json:"token"`
+	Name  string `json:"name,omitempty"`
+}
+
+// PlayCommand is a struct for the command replaying a recording
+// Rate is the speed of the replay from 0.5 to 2, the recorded speed if omitted
+type PlayCommand struct {
+	Token string   `json:"token"`
+	Rate  *float64 `json:"rate,omitempty"`
+}
+
+// PostRecording processes the request to start recording the moves of the joints
+func PostRecording(w http.ResponseWriter, r *http.Request) {
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var recordingCommand RecordingCommand
+	err := decoder.Decode(&recordingCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePostRecording,
+		Value: []interface{}{recordingCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeRecordingStarted: // respond with the started recording
+		id, ok := msg.Value[0].(string)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError) // 500
+			return
+		}
+		log.Printf("[HandlerChannel] RecordingStarted: %v", id)
+		w.Header().Set("Location", APIProto+APIHost+APIBaseURL+"/recordings/"+id)
+		js, _ := json.Marshal(msg.Value[1])
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusCreated) // 201
+		w.Write(js)
+	case TypeInvalidCommand: // invalid name
+		log.Println("[HandlerChannel] InvalidCommand")
+		writeError(w, msg, http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", recordingCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	case TypeRecordingActive: // already recording
+		log.Println("[HandlerChannel] RecordingActive")
+		writeError(w, msg, http.StatusConflict) // 409
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
+
+// PostRecordingStop processes the request to stop and save the active recording
+func PostRecordingStop(w http.ResponseWriter, r *http.Request) {
+	// the id is before /stop
+	id := path.Base(path.Dir(r.URL.Path))
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var token Token
+	err := decoder.Decode(&token)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypeStopRecording,
+		Value: []interface{}{id, token.Token},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // respond with the saved recording
+		log.Printf("[HandlerChannel] StopRecording: %v", id)
+		writeJSON(w, msg.Value[0])
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", token.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeRecordingNotFound: // not the active recording
+		log.Printf("[HandlerChannel] RecordingNotFound: %v", id)
+		w.WriteHeader(http.StatusNotFound) // 404
+	default: // something went wrong, such as writing the file
+		writeError(w, msg, http.StatusInternalServerError) // 500
+	}
+}
+
+// GetRecordings processes the request for the recordings
+func GetRecordings(w http.ResponseWriter, r *http.Request) {
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type: TypeGetRecordings,
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok || msg.Type != TypeRecordings {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	log.Println("[HandlerChannel] Recordings")
+	writeJSON(w, msg.Value[0])
+}
+
+// GetRecording processes the request to download a recording with its frames
+func GetRecording(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypeGetRecording,
+		Value: []interface{}{id},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeCurrentRecording: // respond with the recording
+		writeJSON(w, msg.Value[0])
+	case TypeRecordingNotFound: // no such recording
+		log.Printf("[HandlerChannel] RecordingNotFound: %v", id)
+		w.WriteHeader(http.StatusNotFound) // 404
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
+
+// PatchRecording processes the request to rename a recording
+func PatchRecording(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var recordingCommand RecordingCommand
+	err := decoder.Decode(&recordingCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePatchRecording,
+		Value: []interface{}{id, recordingCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // respond with the renamed recording
+		log.Printf("[HandlerChannel] PatchRecording: %v", id)
+		writeJSON(w, msg.Value[0])
+	case TypeInvalidCommand: // invalid name
+		log.Printf("[HandlerChannel] InvalidCommand: %v", id)
+		writeError(w, msg, http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", recordingCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeRecordingNotFound: // no such recording
+		log.Printf("[HandlerChannel] RecordingNotFound: %v", id)
+		w.WriteHeader(http.StatusNotFound) // 404
+	default: // something went wrong, such as writing the file
+		writeError(w, msg, http.StatusInternalServerError) // 500
+	}
+}
+
+// PostRecordingPlay processes the request to replay a recording as a trajectory
+func PostRecordingPlay(w http.ResponseWriter, r *http.Request) {
+	// the id is before /play
+	id := path.Base(path.Dir(r.URL.Path))
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var playCommand PlayCommand
+	err := decoder.Decode(&playCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePlayRecording,
+		Value: []interface{}{id, playCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeTrajectoryStarted: // respond with the trajectory replaying the recording
+		trajectoryState, ok := msg.Value[0].(TrajectoryState)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError) // 500
+			return
+		}
+		log.Printf("[HandlerChannel] PlayRecording: %v as trajectory %v", id, trajectoryState.ID)
+		w.Header().Set("Location", APIProto+APIHost+APIBaseURL+"/trajectories/"+trajectoryState.ID)
+		js, _ := json.Marshal(trajectoryState)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusCreated) // 201
+		w.Write(js)
+	case TypeInvalidCommand: // invalid rate
+		log.Printf("[HandlerChannel] InvalidCommand: %v", id)
+		writeError(w, msg, http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", playCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeRecordingNotFound: // no such recording
+		log.Printf("[HandlerChannel] RecordingNotFound: %v", id)
+		w.WriteHeader(http.StatusNotFound) // 404
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
+	}
+}
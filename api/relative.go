@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// RelativeCommand is a struct for the command moving a joint by Delta from its current value
+// The Delta is in the Unit, ticks if omitted, and the omitted Speed falls back on the speed of the user
+// Queue waits for the motion commands in line, moving from the value they leave
+type RelativeCommand struct {
+	Token string  `json:"token"`
+	Delta float64 `json:"delta"`
+	Unit  string  `json:"unit,omitempty"`
+	Speed *int    `json:"speed,omitempty"`
+	Queue bool    `json:"queue,omitempty"`
+}
+
+// RelativeResult is the value in ticks, degrees and radians a joint was moved to by a RelativeCommand
+// Clamped says the value was clamped at the limit of the joint, and Command is the ID of the motion command
+type RelativeResult struct {
+	Joint   string  `json:"joint"`
+	Value   int     `json:"value"`
+	Degrees float64 `json:"degrees"`
+	Radians float64 `json:"radians"`
+	Clamped bool    `json:"clamped"`
+	Command string  `json:"command"`
+}
+
+// PatchJoint processes the request for a relative move of a joint
+func PatchJoint(w http.ResponseWriter, r *http.Request) {
+	joint, ok := jointPaths[strings.TrimPrefix(r.URL.Path, APIBaseURL)]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound) // 404
+		return
+	}
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var relativeCommand RelativeCommand
+	err := decoder.Decode(&relativeCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	relativeCommand.Unit = requestUnit(r, relativeCommand.Unit)
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePatchJoint,
+		Value: []interface{}{joint, relativeCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // the requested action is performed
+		log.Printf("[HandlerChannel] PatchJoint: %v %+v", joint, relativeCommand.Delta)
+		if commandState, ok := msg.Value[1].(CommandState); ok {
+			w.Header().Set("Location", APIProto+APIHost+APIBaseURL+"/commands/"+commandState.ID)
+		}
+		writeJSON(w, msg.Value[0])
+	case TypeInvalidCommand: // the value out of the limits
+		log.Printf("[HandlerChannel] InvalidCommand: %v %+v", joint, relativeCommand.Delta)
+		writeError(w, msg, http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", relativeCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
+	}
+}
@@ -7,9 +7,14 @@ import (
 )
 
 // RobotCommand is a struct for the command
+// The Value is in the Unit, ticks if omitted, and the omitted Speed falls back on the speed of the user
+// Queue waits for the motion commands in line instead of taking over, the reset ignores it
 type RobotCommand struct {
-	Token string `json:"token"`
-	Value uint16 `json:"value"`
+	Token string  `json:"token"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+	Speed *int    `json:"speed,omitempty"`
+	Queue bool    `json:"queue,omitempty"`
 }
 
 // PutBase processes the request for Base
@@ -22,6 +27,7 @@ func PutBase(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest) // 400
 		return
 	}
+	robotCommand.Unit = requestUnit(r, robotCommand.Unit)
 	// bypass the request to HandlerChannel
 	HandlerChannel <- HandlerMessage{
 		Type:  TypePutBase,
@@ -38,15 +44,18 @@ func PutBase(w http.ResponseWriter, r *http.Request) {
 	switch msg.Type {
 	case TypeActionPerformed: // the requested action is performed
 		log.Printf("[HandlerChannel] PutBase: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusAccepted) // 202
+		writeAccepted(w, msg)
 	case TypeInvalidCommand: // the invalid value provided
 		log.Printf("[HandlerChannel] InvalidCommand: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusBadRequest) // 400
+		writeError(w, msg, http.StatusBadRequest) // 400
 	case TypeInvalidToken: // the invalid token provided
 		log.Printf("[HandlerChannel] InvalidToken: %v", robotCommand.Token)
 		w.WriteHeader(http.StatusUnauthorized) // 401
-	default: // something went wrong
-		w.WriteHeader(http.StatusInternalServerError) // 500
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
 	}
 }
 
@@ -60,6 +69,7 @@ func PutShoulder(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest) // 400
 		return
 	}
+	robotCommand.Unit = requestUnit(r, robotCommand.Unit)
 	// bypass the request to HandlerChannel
 	HandlerChannel <- HandlerMessage{
 		Type:  TypePutShoulder,
@@ -76,15 +86,18 @@ func PutShoulder(w http.ResponseWriter, r *http.Request) {
 	switch msg.Type {
 	case TypeActionPerformed: // the requested action is performed
 		log.Printf("[HandlerChannel] PutShoulder: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusAccepted) // 202
+		writeAccepted(w, msg)
 	case TypeInvalidCommand: // the invalid value provided
 		log.Printf("[HandlerChannel] InvalidCommand: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusBadRequest) // 400
+		writeError(w, msg, http.StatusBadRequest) // 400
 	case TypeInvalidToken: // the invalid token provided
 		log.Printf("[HandlerChannel] InvalidToken: %v", robotCommand.Token)
 		w.WriteHeader(http.StatusUnauthorized) // 401
-	default: // something went wrong
-		w.WriteHeader(http.StatusInternalServerError) // 500
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
 	}
 }
 
@@ -98,6 +111,7 @@ func PutElbow(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest) // 400
 		return
 	}
+	robotCommand.Unit = requestUnit(r, robotCommand.Unit)
 	// bypass the request to HandlerChannel
 	HandlerChannel <- HandlerMessage{
 		Type:  TypePutElbow,
@@ -114,15 +128,18 @@ func PutElbow(w http.ResponseWriter, r *http.Request) {
 	switch msg.Type {
 	case TypeActionPerformed: // the requested action is performed
 		log.Printf("[HandlerChannel] ElbowRotation: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusAccepted) // 202
+		writeAccepted(w, msg)
 	case TypeInvalidCommand: // the invalid value provided
 		log.Printf("[HandlerChannel] InvalidCommand: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusBadRequest) // 400
+		writeError(w, msg, http.StatusBadRequest) // 400
 	case TypeInvalidToken: // the invalid token provided
 		log.Printf("[HandlerChannel] InvalidToken: %v", robotCommand.Token)
 		w.WriteHeader(http.StatusUnauthorized) // 401
-	default: // something went wrong
-		w.WriteHeader(http.StatusInternalServerError) // 500
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
 	}
 }
 
@@ -136,6 +153,7 @@ func PutWristAngle(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest) // 400
 		return
 	}
+	robotCommand.Unit = requestUnit(r, robotCommand.Unit)
 	// bypass the request to HandlerChannel
 	HandlerChannel <- HandlerMessage{
 		Type:  TypePutWristAngle,
@@ -152,15 +170,18 @@ func PutWristAngle(w http.ResponseWriter, r *http.Request) {
 	switch msg.Type {
 	case TypeActionPerformed: // the requested action is performed
 		log.Printf("[HandlerChannel] WristAngle: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusAccepted) // 202
+		writeAccepted(w, msg)
 	case TypeInvalidCommand: // the invalid value provided
 		log.Printf("[HandlerChannel] InvalidCommand: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusBadRequest) // 400
+		writeError(w, msg, http.StatusBadRequest) // 400
 	case TypeInvalidToken: // the invalid token provided
 		log.Printf("[HandlerChannel] InvalidToken: %v", robotCommand.Token)
 		w.WriteHeader(http.StatusUnauthorized) // 401
-	default: // something went wrong
-		w.WriteHeader(http.StatusInternalServerError) // 500
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
 	}
 }
 
@@ -174,6 +195,7 @@ func PutWristRotation(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest) // 400
 		return
 	}
+	robotCommand.Unit = requestUnit(r, robotCommand.Unit)
 	// bypass the request to HandlerChannel
 	HandlerChannel <- HandlerMessage{
 		Type:  TypePutWristRotation,
@@ -190,15 +212,18 @@ func PutWristRotation(w http.ResponseWriter, r *http.Request) {
 	switch msg.Type {
 	case TypeActionPerformed: // the requested action is performed
 		log.Printf("[HandlerChannel] WristRotation: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusAccepted) // 202
+		writeAccepted(w, msg)
 	case TypeInvalidCommand: // the invalid value provided
 		log.Printf("[HandlerChannel] InvalidCommand: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusBadRequest) // 400
+		writeError(w, msg, http.StatusBadRequest) // 400
 	case TypeInvalidToken: // the invalid token provided
 		log.Printf("[HandlerChannel] InvalidToken: %v", robotCommand.Token)
 		w.WriteHeader(http.StatusUnauthorized) // 401
-	default: // something went wrong
-		w.WriteHeader(http.StatusInternalServerError) // 500
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
 	}
 }
 
@@ -212,6 +237,7 @@ func PutGripper(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest) // 400
 		return
 	}
+	robotCommand.Unit = requestUnit(r, robotCommand.Unit)
 	// bypass the request to HandlerChannel
 	HandlerChannel <- HandlerMessage{
 		Type:  TypePutGripper,
@@ -228,15 +254,18 @@ func PutGripper(w http.ResponseWriter, r *http.Request) {
 	switch msg.Type {
 	case TypeActionPerformed: // the requested action is performed
 		log.Printf("[HandlerChannel] Gripper: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusAccepted) // 202
+		writeAccepted(w, msg)
 	case TypeInvalidCommand: // the invalid value provided
 		log.Printf("[HandlerChannel] InvalidCommand: %v", robotCommand.Value)
-		w.WriteHeader(http.StatusBadRequest) // 400
+		writeError(w, msg, http.StatusBadRequest) // 400
 	case TypeInvalidToken: // the invalid token provided
 		log.Printf("[HandlerChannel] InvalidToken: %v", robotCommand.Token)
 		w.WriteHeader(http.StatusUnauthorized) // 401
-	default: // something went wrong
-		w.WriteHeader(http.StatusInternalServerError) // 500
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
 	}
 }
 
@@ -270,8 +299,14 @@ func PutReset(w http.ResponseWriter, r *http.Request) {
 	case TypeInvalidToken: // the invalid token provided
 		log.Printf("[HandlerChannel] InvalidToken: %v", robotCommand.Token)
 		w.WriteHeader(http.StatusUnauthorized) // 401
-	default: // something went wrong
-		w.WriteHeader(http.StatusInternalServerError) // 500
+	case TypeArmNotResponding: // the reset was not confirmed
+		log.Println("[HandlerChannel] ArmNotResponding")
+		w.WriteHeader(http.StatusGatewayTimeout) // 504
+	case TypeArmError: // the arm replied with an error
+		log.Printf("[HandlerChannel] ArmError: %v", msg.Value[0])
+		w.WriteHeader(http.StatusBadGateway) // 502
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
 	}
 
 }
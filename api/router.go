@@ -91,12 +91,246 @@ var routes = Routes{
 		APIBaseURL + "/gripper",
 		PutGripper,
 	},
+	Route{
+		"GetPose",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/pose",
+		GetPose,
+	},
+	Route{
+		"GetCartesian",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/pose/cartesian",
+		GetCartesian,
+	},
+	Route{
+		"PutCartesian",
+		strings.ToUpper("Put"),
+		APIBaseURL + "/cartesian",
+		PutCartesian,
+	},
+	Route{
+		"GetBase",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/base",
+		GetJoint,
+	},
+	Route{
+		"GetShoulder",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/shoulder",
+		GetJoint,
+	},
+	Route{
+		"GetElbow",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/elbow",
+		GetJoint,
+	},
+	Route{
+		"GetWristAngle",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/wrist/angle",
+		GetJoint,
+	},
+	Route{
+		"GetWristRotation",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/wrist/rotation",
+		GetJoint,
+	},
+	Route{
+		"GetGripper",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/gripper",
+		GetJoint,
+	},
+	Route{
+		"PatchBase",
+		strings.ToUpper("Patch"),
+		APIBaseURL + "/base",
+		PatchJoint,
+	},
+	Route{
+		"PatchShoulder",
+		strings.ToUpper("Patch"),
+		APIBaseURL + "/shoulder",
+		PatchJoint,
+	},
+	Route{
+		"PatchElbow",
+		strings.ToUpper("Patch"),
+		APIBaseURL + "/elbow",
+		PatchJoint,
+	},
+	Route{
+		"PatchWristAngle",
+		strings.ToUpper("Patch"),
+		APIBaseURL + "/wrist/angle",
+		PatchJoint,
+	},
+	Route{
+		"PatchWristRotation",
+		strings.ToUpper("Patch"),
+		APIBaseURL + "/wrist/rotation",
+		PatchJoint,
+	},
+	Route{
+		"PatchGripper",
+		strings.ToUpper("Patch"),
+		APIBaseURL + "/gripper",
+		PatchJoint,
+	},
+	Route{
+		"PutPose",
+		strings.ToUpper("Put"),
+		APIBaseURL + "/pose",
+		PutPose,
+	},
 	Route{
 		"PutReset",
 		strings.ToUpper("Put"),
 		APIBaseURL + "/reset",
 		PutReset,
 	},
+	Route{
+		"PutMode",
+		strings.ToUpper("Put"),
+		APIBaseURL + "/mode",
+		PutMode,
+	},
+	Route{
+		"PutPosition",
+		strings.ToUpper("Put"),
+		APIBaseURL + "/position",
+		PutPosition,
+	},
+	Route{
+		"GetPoses",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/poses",
+		GetPoses,
+	},
+	Route{
+		"PutNamedPose",
+		strings.ToUpper("Put"),
+		APIBaseURL + "/poses/{name}",
+		PutNamedPose,
+	},
+	Route{
+		"PostNamedPose",
+		strings.ToUpper("Post"),
+		APIBaseURL + "/poses/{name}/go",
+		PostNamedPose,
+	},
+	Route{
+		"PostTrajectory",
+		strings.ToUpper("Post"),
+		APIBaseURL + "/trajectories",
+		PostTrajectory,
+	},
+	Route{
+		"GetTrajectory",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/trajectories/{id}",
+		GetTrajectory,
+	},
+	Route{
+		"DeleteTrajectory",
+		strings.ToUpper("Delete"),
+		APIBaseURL + "/trajectories/{id}",
+		DeleteTrajectory,
+	},
+	Route{
+		"PostProgram",
+		strings.ToUpper("Post"),
+		APIBaseURL + "/programs",
+		PostProgram,
+	},
+	Route{
+		"GetProgram",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/programs/{id}",
+		GetProgram,
+	},
+	Route{
+		"DeleteProgram",
+		strings.ToUpper("Delete"),
+		APIBaseURL + "/programs/{id}",
+		DeleteProgram,
+	},
+	Route{
+		"GetRecordings",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/recordings",
+		GetRecordings,
+	},
+	Route{
+		"PostRecording",
+		strings.ToUpper("Post"),
+		APIBaseURL + "/recordings",
+		PostRecording,
+	},
+	Route{
+		"GetRecording",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/recordings/{id}",
+		GetRecording,
+	},
+	Route{
+		"PatchRecording",
+		strings.ToUpper("Patch"),
+		APIBaseURL + "/recordings/{id}",
+		PatchRecording,
+	},
+	Route{
+		"PostRecordingStop",
+		strings.ToUpper("Post"),
+		APIBaseURL + "/recordings/{id}/stop",
+		PostRecordingStop,
+	},
+	Route{
+		"PostRecordingPlay",
+		strings.ToUpper("Post"),
+		APIBaseURL + "/recordings/{id}/play",
+		PostRecordingPlay,
+	},
+	Route{
+		"GetCommand",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/commands/{id}",
+		GetCommand,
+	},
+	Route{
+		"DeleteCommand",
+		strings.ToUpper("Delete"),
+		APIBaseURL + "/commands/{id}",
+		DeleteCommand,
+	},
+	Route{
+		"GetLimits",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/limits",
+		GetLimits,
+	},
+	Route{
+		"PutStop",
+		strings.ToUpper("Put"),
+		APIBaseURL + "/stop",
+		PutStop,
+	},
+	Route{
+		"PutRearm",
+		strings.ToUpper("Put"),
+		APIBaseURL + "/rearm",
+		PutRearm,
+	},
+	Route{
+		"GetTelemetry",
+		strings.ToUpper("Get"),
+		APIBaseURL + "/telemetry",
+		GetTelemetry,
+	},
 }
 
 // Logger handles the logging in the router
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+)
+
+// StopCommand is a struct for the emergency stop
+// The body is optional, anyone can stop the robot
+type StopCommand struct {
+	Token  string `json:"token,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Source string `json:"-"`
+}
+
+// PutStop processes the request for the emergency stop
+func PutStop(w http.ResponseWriter, r *http.Request) {
+	// parse the request body, if any
+	decoder := json.NewDecoder(r.Body)
+	var stopCommand StopCommand
+	err := decoder.Decode(&stopCommand)
+	if err != nil && err != io.EOF {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	stopCommand.Source = r.RemoteAddr
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePutStop,
+		Value: []interface{}{stopCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // the requested action is performed
+		log.Printf("[HandlerChannel] PutStop: %v", stopCommand.Source)
+		w.WriteHeader(http.StatusAccepted) // 202
+	case TypeRobotDisconnected: // the link to the robot is down, the stop is sent once it is back
+		log.Println("[HandlerChannel] RobotDisconnected")
+		w.WriteHeader(http.StatusServiceUnavailable) // 503
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
+
+// PutRearm processes the request to resume the motion after an emergency stop
+func PutRearm(w http.ResponseWriter, r *http.Request) {
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var token Token
+	err := decoder.Decode(&token)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePutRearm,
+		Value: []interface{}{token.Token},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // the requested action is performed
+		log.Println("[HandlerChannel] PutRearm")
+		w.WriteHeader(http.StatusAccepted) // 202
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", token.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeNotStopped: // nothing to re-arm
+		log.Println("[HandlerChannel] NotStopped")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
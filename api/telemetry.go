@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// GetTelemetry processes the request for the state of the servos
+func GetTelemetry(w http.ResponseWriter, r *http.Request) {
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type: TypeGetTelemetry,
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeTelemetry: // respond with the state of each servo
+		log.Println("[HandlerChannel] Telemetry")
+		js, err := json.Marshal(msg.Value[0])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK) // 200
+		w.Write(js)
+	case TypeNotSupported: // the backend gives no feedback
+		log.Println("[HandlerChannel] NotSupported")
+		w.WriteHeader(http.StatusNotImplemented) // 501
+	case TypeRobotDisconnected: // the link to the robot is down
+		log.Println("[HandlerChannel] RobotDisconnected")
+		w.WriteHeader(http.StatusServiceUnavailable) // 503
+	case TypeArmNotResponding: // the servos did not reply
+		log.Printf("[HandlerChannel] ArmNotResponding: %v", msg.Value[0])
+		writeError(w, msg, http.StatusGatewayTimeout) // 504
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"path"
+)
+
+// The statuses of a trajectory
+const (
+	TrajectoryRunning  = "running"
+	TrajectoryDone     = "done"
+	TrajectoryCanceled = "canceled"
+	TrajectoryStopped  = "stopped"
+	TrajectoryFailed   = "failed"
+)
+
+// TrajectoryWaypoint is a pose of a trajectory reached Duration milliseconds after the previous one
+// The omitted joints keep the values of the previous waypoint
+type TrajectoryWaypoint struct {
+	Base          *float64 `json:"base,omitempty"`
+	Shoulder      *float64 `json:"shoulder,omitempty"`
+	Elbow         *float64 `json:"elbow,omitempty"`
+	WristAngle    *float64 `json:"wristAngle,omitempty"`
+	WristRotation *float64 `json:"wristRotation,omitempty"`
+	Gripper       *float64 `json:"gripper,omitempty"`
+	Duration      int      `json:"duration"`
+}
+
+// TrajectoryCommand is a struct for the command moving the joints through the Waypoints,
+// or to the single target given inline if there are none
+// The values are in the Unit, ticks if omitted, and the Interpolation is minjerk if omitted
+type TrajectoryCommand struct {
+	Token string `json:"token"`
+	TrajectoryWaypoint
+	Waypoints     []TrajectoryWaypoint `json:"waypoints,omitempty"`
+	Unit          string               `json:"unit,omitempty"`
+	Interpolation string               `json:"interpolation,omitempty"`
+}
+
+// TrajectoryState is the progress of a trajectory, Waypoint is the index of the one being approached
+// and Elapsed and Duration are in milliseconds
+type TrajectoryState struct {
+	ID            string  `json:"id"`
+	Status        string  `json:"status"`
+	Interpolation string  `json:"interpolation"`
+	Waypoint      int     `json:"waypoint"`
+	Waypoints     int     `json:"waypoints"`
+	Elapsed       int     `json:"elapsed"`
+	Duration      int     `json:"duration"`
+	Progress      float64 `json:"progress"`
+}
+
+// PostTrajectory processes the request to start a trajectory
+func PostTrajectory(w http.ResponseWriter, r *http.Request) {
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var trajectoryCommand TrajectoryCommand
+	err := decoder.Decode(&trajectoryCommand)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	trajectoryCommand.Unit = requestUnit(r, trajectoryCommand.Unit)
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypePostTrajectory,
+		Value: []interface{}{trajectoryCommand},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeTrajectoryStarted: // respond with the started trajectory
+		trajectoryState, ok := msg.Value[0].(TrajectoryState)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError) // 500
+			return
+		}
+		log.Printf("[HandlerChannel] TrajectoryStarted: %v", trajectoryState.ID)
+		w.Header().Set("Location", APIProto+APIHost+APIBaseURL+"/trajectories/"+trajectoryState.ID)
+		js, _ := json.Marshal(trajectoryState)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusCreated) // 201
+		w.Write(js)
+	case TypeInvalidCommand: // invalid waypoints
+		log.Println("[HandlerChannel] InvalidCommand")
+		writeError(w, msg, http.StatusBadRequest) // 400
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", trajectoryCommand.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeWrongMode: // not in Joint mode
+		log.Println("[HandlerChannel] WrongMode")
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // rejected by the controller, or something went wrong
+		writeRobotError(w, msg)
+	}
+}
+
+// GetTrajectory processes the request for the progress of a trajectory
+func GetTrajectory(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypeGetTrajectory,
+		Value: []interface{}{id},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeCurrentTrajectory: // respond with the progress
+		writeJSON(w, msg.Value[0])
+	case TypeTrajectoryNotFound: // not the latest trajectory
+		log.Printf("[HandlerChannel] TrajectoryNotFound: %v", id)
+		w.WriteHeader(http.StatusNotFound) // 404
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
+
+// DeleteTrajectory processes the request to cancel a trajectory, the arm stays where it is
+func DeleteTrajectory(w http.ResponseWriter, r *http.Request) {
+	id := path.Base(r.URL.Path)
+	// parse the request body
+	decoder := json.NewDecoder(r.Body)
+	var token Token
+	err := decoder.Decode(&token)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest) // 400
+		return
+	}
+	// bypass the request to HandlerChannel
+	HandlerChannel <- HandlerMessage{
+		Type:  TypeDeleteTrajectory,
+		Value: []interface{}{id, token.Token},
+	}
+	// receive a message from the other end of HandlerChannel
+	msg, ok := <-HandlerChannel
+	// check the channel status
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError) // 500
+		return
+	}
+	// respond with the result
+	switch msg.Type {
+	case TypeActionPerformed: // the trajectory is canceled
+		log.Printf("[HandlerChannel] DeleteTrajectory: %v", id)
+		writeJSON(w, msg.Value[0])
+	case TypeInvalidToken: // the invalid token provided
+		log.Printf("[HandlerChannel] InvalidToken: %v", token.Token)
+		w.WriteHeader(http.StatusUnauthorized) // 401
+	case TypeTrajectoryNotFound: // not the latest trajectory
+		log.Printf("[HandlerChannel] TrajectoryNotFound: %v", id)
+		w.WriteHeader(http.StatusNotFound) // 404
+	case TypeTrajectoryFinished: // nothing to cancel
+		log.Printf("[HandlerChannel] TrajectoryFinished: %v", id)
+		w.WriteHeader(http.StatusConflict) // 409
+	default: // something went wrong
+		w.WriteHeader(http.StatusInternalServerError) // 500
+	}
+}
@@ -11,17 +11,22 @@ type User struct {
 	Name  string
 	Email string
 	Token string
+	Speed *int
 }
 
+// UserInfo is the user in the API
+// Speed is the default speed of the moves of the user, if any
 type UserInfo struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	Speed *int   `json:"speed,omitempty"`
 }
 
 func (u *User) ToUserInfo() UserInfo {
 	return UserInfo{
 		Name:  u.Name,
 		Email: u.Email,
+		Speed: u.Speed,
 	}
 }
 
@@ -30,6 +35,7 @@ func NewUser(userInfo *UserInfo) *User {
 		Name:  userInfo.Name,
 		Email: userInfo.Email,
 		Token: GenerateToken(),
+		Speed: userInfo.Speed,
 	}
 }
 
@@ -71,6 +77,12 @@ func AddUser(w http.ResponseWriter, r *http.Request) {
 	case TypeInvalidUserInfo: // invalid email
 		log.Printf("[HandlerChannel] Invalid UserInfo (name, email) = %v, %v", userInfo.Name, userInfo.Email)
 		w.WriteHeader(http.StatusBadRequest)
+	case TypeArmNotResponding: // the reset to home was not confirmed
+		log.Println("[HandlerChannel] ArmNotResponding")
+		w.WriteHeader(http.StatusGatewayTimeout) // 504
+	case TypeArmError: // the arm replied with an error to the reset
+		log.Printf("[HandlerChannel] ArmError: %v", msg.Value[0])
+		w.WriteHeader(http.StatusBadGateway) // 502
 	default: // something went wrong
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -0,0 +1,134 @@
+package armlink
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// captureHeader is the first line of a capture file
+const captureHeader = "# armlink capture v1"
+
+// CaptureFrame is a frame sent Offset after the capture started
+type CaptureFrame struct {
+	Offset time.Duration
+	Bytes  []byte
+}
+
+// Recorder is a Transport writing every frame sent through the underlying Transport
+// to a capture, one line per frame with its offset in nanoseconds and its bytes in hex
+type Recorder struct {
+	Transport
+	mu      sync.Mutex
+	capture io.WriteCloser
+	start   time.Time
+}
+
+// NewRecorder creates a new Recorder writing the capture of the Transport to w
+func NewRecorder(t Transport, w io.WriteCloser) (*Recorder, error) {
+	if _, err := fmt.Fprintln(w, captureHeader); err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		Transport: t,
+		capture:   w,
+		start:     time.Now(),
+	}, nil
+}
+
+// Send sends the bytes through the Transport, and records them once sent
+func (rec *Recorder) Send(b []byte) error {
+	// time.Since uses the monotonic clock
+	offset := time.Since(rec.start)
+	if err := rec.Transport.Send(b); err != nil {
+		return err
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	// the frame went to the arm, a capture missing it is no reason to fail the send
+	if _, err := fmt.Fprintf(rec.capture, "%d %x\n", offset.Nanoseconds(), b); err != nil {
+		log.Printf("[Capture] %v", err)
+	}
+	return nil
+}
+
+// Close closes the capture and the Transport
+func (rec *Recorder) Close() error {
+	rec.mu.Lock()
+	err := rec.capture.Close()
+	rec.mu.Unlock()
+	if terr := rec.Transport.Close(); terr != nil {
+		return terr
+	}
+	return err
+}
+
+// Connected reports if the link of the Transport is up
+func (rec *Recorder) Connected() bool {
+	if r, ok := rec.Transport.(Reconnector); ok {
+		return r.Connected()
+	}
+	return true
+}
+
+// Reconnected returns the channel notified when the link of the Transport is back, nil if it never reconnects
+func (rec *Recorder) Reconnected() <-chan struct{} {
+	if r, ok := rec.Transport.(Reconnector); ok {
+		return r.Reconnected()
+	}
+	return nil
+}
+
+// Telemetry returns the state of the servos from the Transport
+func (rec *Recorder) Telemetry() ([]ServoState, error) {
+	if t, ok := rec.Transport.(Telemeter); ok {
+		return t.Telemetry()
+	}
+	return nil, ErrNoTelemetry
+}
+
+// ReadCapture reads all the frames from a capture written by a Recorder
+func ReadCapture(r io.Reader) ([]CaptureFrame, error) {
+	frames := []CaptureFrame{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if line == 1 && text != captureHeader {
+			return nil, fmt.Errorf("capture: not a capture file, expected %q", captureHeader)
+		}
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("capture: line %v: expected offset and bytes", line)
+		}
+		offset, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("capture: line %v: %v", line, err)
+		}
+		b, err := hex.DecodeString(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("capture: line %v: %v", line, err)
+		}
+		frames = append(frames, CaptureFrame{
+			Offset: time.Duration(offset),
+			Bytes:  b,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if line == 0 {
+		return nil, fmt.Errorf("capture: empty capture file")
+	}
+	return frames, nil
+}
@@ -0,0 +1,53 @@
+package armlink
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// FTDIUSBID is the USB vendor:product ID of the FTDI-USB cable
+const FTDIUSBID = "0403:6001"
+
+// sysClassTTY lists the tty devices on Linux
+const sysClassTTY = "/sys/class/tty"
+
+// FindSerialPort returns the device path of the first serial port
+// whose USB device matches the usbID in the form of "vendor:product"
+func FindSerialPort(usbID string) (string, error) {
+	ids := strings.Split(strings.ToLower(usbID), ":")
+	if len(ids) != 2 {
+		return "", fmt.Errorf("invalid USB ID %q, expected vendor:product", usbID)
+	}
+	ttys, err := ioutil.ReadDir(sysClassTTY)
+	if err != nil {
+		return "", err
+	}
+	for _, tty := range ttys {
+		name := tty.Name()
+		if !strings.HasPrefix(name, "ttyUSB") && !strings.HasPrefix(name, "ttyACM") {
+			continue
+		}
+		dev, err := filepath.EvalSymlinks(filepath.Join(sysClassTTY, name, "device"))
+		if err != nil {
+			continue
+		}
+		// walk up to the USB device holding the IDs
+		for dir := dev; dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+			vendor, err := ioutil.ReadFile(filepath.Join(dir, "idVendor"))
+			if err != nil {
+				continue
+			}
+			product, err := ioutil.ReadFile(filepath.Join(dir, "idProduct"))
+			if err != nil {
+				break
+			}
+			if strings.TrimSpace(string(vendor)) == ids[0] && strings.TrimSpace(string(product)) == ids[1] {
+				return filepath.Join("/dev", name), nil
+			}
+			break
+		}
+	}
+	return "", fmt.Errorf("no serial port found for the USB ID %v", usbID)
+}
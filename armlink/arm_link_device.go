@@ -0,0 +1,67 @@
+package armlink
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// OpenFunc opens the Transport for a device URL with a registered scheme
+type OpenFunc func(u *url.URL, config SerialConfig) (Transport, error)
+
+var (
+	devicesMutex sync.Mutex
+	devices      = map[string]OpenFunc{}
+)
+
+// RegisterDevice makes the scheme available to Open, for the backends outside of armlink
+func RegisterDevice(scheme string, open OpenFunc) {
+	devicesMutex.Lock()
+	defer devicesMutex.Unlock()
+	if _, ok := devices[scheme]; ok {
+		panic("armlink: RegisterDevice called twice for " + scheme)
+	}
+	devices[scheme] = open
+}
+
+// Open opens the Transport for the device URL
+//
+//	serial:///dev/ttyUSB0    the serial port, config.PortName if the path is empty
+//	tcp://pi.local:4000      a raw TCP bridge such as ser2net
+//	rfc2217://pi.local:4000  a Telnet COM Port Control bridge, configured with the config
+//	sim://                   the built-in Simulator
+//
+// A device without a scheme is taken as the path to a serial port.
+// The other schemes are looked up among the ones registered with RegisterDevice.
+func Open(device string, config SerialConfig) (Transport, error) {
+	u, err := url.Parse(device)
+	if err != nil {
+		return nil, fmt.Errorf("invalid device %q: %v", device, err)
+	}
+	switch u.Scheme {
+	case "", "serial":
+		if u.Path != "" {
+			config.PortName = u.Path
+		}
+		return NewArmLinkSerial(config)
+	case "tcp":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid device %q: missing host:port", device)
+		}
+		return NewArmLinkTCP(u.Host)
+	case "rfc2217":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid device %q: missing host:port", device)
+		}
+		return NewArmLinkRFC2217(u.Host, config)
+	case "sim":
+		return NewSimulator(), nil
+	}
+	devicesMutex.Lock()
+	open, ok := devices[u.Scheme]
+	devicesMutex.Unlock()
+	if ok {
+		return open(u, config)
+	}
+	return nil, fmt.Errorf("invalid device %q: unknown scheme %v", device, u.Scheme)
+}
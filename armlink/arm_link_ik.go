@@ -0,0 +1,95 @@
+package armlink
+
+// Offsets added by the firmware protocol to the signed IK parameters
+const (
+	xOffset          = 512
+	wristAngleOffset = 90
+)
+
+// CartesianPosition holds the parameters of the 3D Cartesian modes
+// X, Y and Z are in the firmware units (~mm) and WristAngle is in degrees
+type CartesianPosition struct {
+	X             int
+	Y             int
+	Z             int
+	WristAngle    int
+	WristRotation uint16
+	Gripper       uint16
+}
+
+// Validate returns a RangeError if any parameter is out of its Limit
+func (cp CartesianPosition) Validate() error {
+	return checkAll(ModeCartesianStraight, ModeLimits(ModeCartesianStraight),
+		cp.X,
+		cp.Y,
+		cp.Z,
+		cp.WristAngle,
+		int(cp.WristRotation),
+		int(cp.Gripper),
+	)
+}
+
+// NewCartesianPacket creates a new ArmLinkPacket moving to the CartesianPosition
+func NewCartesianPacket(cp CartesianPosition, delta byte) (*ArmLinkPacket, error) {
+	if err := cp.Validate(); err != nil {
+		return nil, err
+	}
+	if err := DeltaLimit.Check(ModeCartesianStraight, int(delta)); err != nil {
+		return nil, err
+	}
+	return NewArmLinkPacket(
+		uint16(cp.X+xOffset),
+		uint16(cp.Y),
+		uint16(cp.Z),
+		uint16(cp.WristAngle+wristAngleOffset),
+		cp.WristRotation,
+		cp.Gripper,
+		delta,
+		0,
+		0,
+	), nil
+}
+
+// CylindricalPosition holds the parameters of the 3D Cylindrical modes
+// Base is the base rotation, Y and Z are in the firmware units (~mm) and WristAngle is in degrees
+type CylindricalPosition struct {
+	Base          uint16
+	Y             int
+	Z             int
+	WristAngle    int
+	WristRotation uint16
+	Gripper       uint16
+}
+
+// Validate returns a RangeError if any parameter is out of its Limit
+func (cp CylindricalPosition) Validate() error {
+	return checkAll(ModeCylindricalStraight, ModeLimits(ModeCylindricalStraight),
+		int(cp.Base),
+		cp.Y,
+		cp.Z,
+		cp.WristAngle,
+		int(cp.WristRotation),
+		int(cp.Gripper),
+	)
+}
+
+// NewCylindricalPacket creates a new ArmLinkPacket moving to the CylindricalPosition
+func NewCylindricalPacket(cp CylindricalPosition, delta byte) (*ArmLinkPacket, error) {
+	if err := cp.Validate(); err != nil {
+		return nil, err
+	}
+	if err := DeltaLimit.Check(ModeCylindricalStraight, int(delta)); err != nil {
+		return nil, err
+	}
+	return NewArmLinkPacket(
+		cp.Base,
+		uint16(cp.Y),
+		uint16(cp.Z),
+		uint16(cp.WristAngle+wristAngleOffset),
+		cp.WristRotation,
+		cp.Gripper,
+		delta,
+		0,
+		0,
+	), nil
+}
@@ -0,0 +1,98 @@
+package armlink
+
+// JointPosition holds the parameters of the Backhoe/Joint mode
+// Each of them is the goal position of the servo (0-1023)
+type JointPosition struct {
+	Base          uint16
+	Shoulder      uint16
+	Elbow         uint16
+	WristAngle    uint16
+	WristRotation uint16
+	Gripper       uint16
+}
+
+// Validate returns a RangeError if any parameter is out of its Limit
+func (jp JointPosition) Validate() error {
+	return checkAll(ModeBackhoe, ModeLimits(ModeBackhoe),
+		int(jp.Base),
+		int(jp.Shoulder),
+		int(jp.Elbow),
+		int(jp.WristAngle),
+		int(jp.WristRotation),
+		int(jp.Gripper),
+	)
+}
+
+// NewJointPacket creates a new ArmLinkPacket moving to the JointPosition
+func NewJointPacket(jp JointPosition, delta byte) (*ArmLinkPacket, error) {
+	if err := jp.Validate(); err != nil {
+		return nil, err
+	}
+	if err := DeltaLimit.Check(ModeBackhoe, int(delta)); err != nil {
+		return nil, err
+	}
+	return NewArmLinkPacket(
+		jp.Base,
+		jp.Shoulder,
+		jp.Elbow,
+		jp.WristAngle,
+		jp.WristRotation,
+		jp.Gripper,
+		delta,
+		0,
+		0,
+	), nil
+}
+
+// SleepPosition is where the firmware rests the arm on ExtendedSleep
+var SleepPosition = JointPosition{
+	Base:          512,
+	Shoulder:      205,
+	Elbow:         210,
+	WristAngle:    512,
+	WristRotation: 512,
+	Gripper:       256,
+}
+
+// HomePosition returns the defaults of the Limits, where the firmware moves the arm on ExtendedReset
+// unless SetJointLimits changed them
+func HomePosition() JointPosition {
+	return JointPosition{
+		Base:          uint16(BaseLimit.Default),
+		Shoulder:      uint16(ShoulderLimit.Default),
+		Elbow:         uint16(ElbowLimit.Default),
+		WristAngle:    uint16(WristAngleLimit.Default),
+		WristRotation: uint16(WristRotationLimit.Default),
+		Gripper:       uint16(GripperLimit.Default),
+	}
+}
+
+// RestPosition returns the SleepPosition brought within the Limits,
+// as SetJointLimits may have narrowed them
+func RestPosition() JointPosition {
+	return JointPosition{
+		Base:          uint16(BaseLimit.Clamp(int(SleepPosition.Base))),
+		Shoulder:      uint16(ShoulderLimit.Clamp(int(SleepPosition.Shoulder))),
+		Elbow:         uint16(ElbowLimit.Clamp(int(SleepPosition.Elbow))),
+		WristAngle:    uint16(WristAngleLimit.Clamp(int(SleepPosition.WristAngle))),
+		WristRotation: uint16(WristRotationLimit.Clamp(int(SleepPosition.WristRotation))),
+		Gripper:       uint16(GripperLimit.Clamp(int(SleepPosition.Gripper))),
+	}
+}
+
+// JointPosition returns the position parameters of the ArmLinkPacket in the Backhoe/Joint mode
+func (alp *ArmLinkPacket) JointPosition() JointPosition {
+	return JointPosition{
+		Base:          alp.baseRotation,
+		Shoulder:      alp.shoulderRotation,
+		Elbow:         alp.elbowRotation,
+		WristAngle:    alp.wristAngle,
+		WristRotation: alp.wristRotation,
+		Gripper:       alp.gripper,
+	}
+}
+
+// joints returns the JointPosition in the order of the ArmLink frame
+func (jp JointPosition) joints() [jointCount]uint16 {
+	return [jointCount]uint16{jp.Base, jp.Shoulder, jp.Elbow, jp.WristAngle, jp.WristRotation, jp.Gripper}
+}
@@ -0,0 +1,197 @@
+package armlink
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+//go:generate go run ../cmd/openapi-limits --spec ../openapi.yaml
+
+// Limit is the legal range of a parameter in an ArmLinkPacket
+type Limit struct {
+	Name    string
+	Min     int
+	Max     int
+	Default int
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("[%d-%d]", l.Min, l.Max)
+}
+
+// Contains reports if v is within the Limit
+func (l Limit) Contains(v int) bool {
+	return l.Min <= v && v <= l.Max
+}
+
+// Clamp returns v brought within the Limit
+func (l Limit) Clamp(v int) int {
+	if v < l.Min {
+		return l.Min
+	}
+	if v > l.Max {
+		return l.Max
+	}
+	return v
+}
+
+// Check returns a RangeError if v is out of the Limit in the mode
+func (l Limit) Check(mode Mode, v int) error {
+	if !l.Contains(v) {
+		return &RangeError{
+			Mode:  mode,
+			Limit: l,
+			Value: v,
+		}
+	}
+	return nil
+}
+
+// RangeError is returned when a parameter is out of its Limit
+type RangeError struct {
+	Mode  Mode
+	Limit Limit
+	Value int
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("armlink: %v %d is out of the range %v in %v mode", e.Limit.Name, e.Value, e.Limit, e.Mode)
+}
+
+// Limits of the Reactor arm taken from the ArmLink reference
+// https://learn.trossenrobotics.com/arbotix/arbotix-communication-controllers/31-arm-link-reference.html
+var (
+	// Backhoe/Joint mode
+	BaseLimit          = Limit{Name: "base", Min: 0, Max: 1023, Default: 512}
+	ShoulderLimit      = Limit{Name: "shoulder", Min: 205, Max: 810, Default: 400}
+	ElbowLimit         = Limit{Name: "elbow", Min: 210, Max: 900, Default: 400}
+	WristAngleLimit    = Limit{Name: "wristAngle", Min: 200, Max: 830, Default: 580}
+	WristRotationLimit = Limit{Name: "wristRotation", Min: 0, Max: 1023, Default: 512}
+	GripperLimit       = Limit{Name: "gripper", Min: 0, Max: 512, Default: 128}
+
+	// 3D Cartesian and Cylindrical modes
+	XLimit            = Limit{Name: "x", Min: -300, Max: 300, Default: 0}
+	YLimit            = Limit{Name: "y", Min: 50, Max: 350, Default: 200}
+	ZLimit            = Limit{Name: "z", Min: 20, Max: 250, Default: 200}
+	IKWristAngleLimit = Limit{Name: "wristAngle", Min: -30, Max: 30, Default: 0}
+
+	// all modes
+	DeltaLimit    = Limit{Name: "delta", Min: 0, Max: 254, Default: 128}
+	ButtonLimit   = Limit{Name: "button", Min: 0, Max: 127, Default: 0}
+	ExtendedLimit = Limit{Name: "extended", Min: 0, Max: 254, Default: 0}
+)
+
+// ModeLimits returns the Limits of the six position parameters in the mode
+// in the order of the ArmLinkPacket fields, nil for ModeUnknown
+func ModeLimits(mode Mode) []Limit {
+	switch {
+	case mode == ModeBackhoe:
+		return []Limit{BaseLimit, ShoulderLimit, ElbowLimit, WristAngleLimit, WristRotationLimit, GripperLimit}
+	case mode.IsCartesian():
+		return []Limit{XLimit, YLimit, ZLimit, IKWristAngleLimit, WristRotationLimit, GripperLimit}
+	case mode.IsCylindrical():
+		return []Limit{BaseLimit, YLimit, ZLimit, IKWristAngleLimit, WristRotationLimit, GripperLimit}
+	}
+	return nil
+}
+
+// FirmwareLimits are the Limits of the joints in the firmware, which an installation may only narrow
+var FirmwareLimits = ModeLimits(ModeBackhoe)
+
+// JointRange is the range of a joint on an installation, with its Default in the home pose
+// The omitted Default keeps the one of the firmware
+type JointRange struct {
+	Min     int  `json:"min"`
+	Max     int  `json:"max"`
+	Default *int `json:"default,omitempty"`
+}
+
+// LoadLimits reads the Limits of the joints on an installation from a JSON file such as
+//
+//	{"gripper": {"min": 0, "max": 300, "default": 150}}
+//
+// in the order of ModeLimits(ModeBackhoe). The joints missing from the file keep the FirmwareLimits.
+func LoadLimits(path string) ([]Limit, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var joints map[string]JointRange
+	if err := json.Unmarshal(b, &joints); err != nil {
+		return nil, fmt.Errorf("armlink: invalid limits %v: %v", path, err)
+	}
+	limits := append([]Limit{}, FirmwareLimits...)
+	for name, r := range joints {
+		i := 0
+		for i < len(limits) && limits[i].Name != name {
+			i++
+		}
+		if i == len(limits) {
+			return nil, fmt.Errorf("armlink: invalid limits %v: unknown joint %q", path, name)
+		}
+		firmware := FirmwareLimits[i]
+		l := Limit{Name: name, Min: r.Min, Max: r.Max, Default: firmware.Default}
+		if r.Default != nil {
+			l.Default = *r.Default
+		}
+		switch {
+		case l.Min > l.Max:
+			return nil, fmt.Errorf("armlink: invalid limits %v: %v min %d is above max %d", path, name, l.Min, l.Max)
+		case !firmware.Contains(l.Min) || !firmware.Contains(l.Max):
+			return nil, fmt.Errorf("armlink: invalid limits %v: %v %v is beyond the firmware range %v", path, name, l, firmware)
+		case !l.Contains(l.Default):
+			return nil, fmt.Errorf("armlink: invalid limits %v: %v default %d is out of the range %v", path, name, l.Default, l)
+		}
+		limits[i] = l
+	}
+	return limits, nil
+}
+
+// SetJointLimits replaces the Limits of the joints with the limits in the order of ModeLimits(ModeBackhoe),
+// at startup before any packet is built
+func SetJointLimits(limits []Limit) {
+	BaseLimit, ShoulderLimit, ElbowLimit, WristAngleLimit, WristRotationLimit, GripperLimit =
+		limits[0], limits[1], limits[2], limits[3], limits[4], limits[5]
+}
+
+// checkAll returns the first RangeError among the values checked against the limits
+func checkAll(mode Mode, limits []Limit, values ...int) error {
+	for i, l := range limits {
+		if err := l.Check(mode, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate returns a RangeError if any parameter of the ArmLinkPacket is out of its Limit in the mode
+// The position parameters are ignored when an extended instruction is set
+func (alp *ArmLinkPacket) Validate(mode Mode) error {
+	if err := checkAll(mode, []Limit{DeltaLimit, ButtonLimit, ExtendedLimit},
+		int(alp.deltaByte),
+		int(alp.buttonByte),
+		int(alp.extendedInstructionByte),
+	); err != nil {
+		return err
+	}
+	if alp.extendedInstructionByte != 0 {
+		return nil
+	}
+	values := []int{
+		int(alp.baseRotation),
+		int(alp.shoulderRotation),
+		int(alp.elbowRotation),
+		int(alp.wristAngle),
+		int(alp.wristRotation),
+		int(alp.gripper),
+	}
+	// the IK parameters are sent with the offsets
+	if mode.IsCartesian() {
+		values[0] -= xOffset
+	}
+	if mode.IsCartesian() || mode.IsCylindrical() {
+		values[3] -= wristAngleOffset
+	}
+	return checkAll(mode, ModeLimits(mode), values...)
+}
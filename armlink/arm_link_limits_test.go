@@ -0,0 +1,170 @@
+package armlink
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "limits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	gripper := Limit{Name: "gripper", Min: 0, Max: 300, Default: 150}
+	shoulder := Limit{Name: "shoulder", Min: 250, Max: 700, Default: ShoulderLimit.Default}
+	tests := []struct {
+		name    string
+		json    string
+		want    map[string]Limit
+		wantErr bool
+	}{
+		{"empty", `{}`, nil, false},
+		{"a narrower gripper", `{"gripper": {"min": 0, "max": 300, "default": 150}}`, map[string]Limit{"gripper": gripper}, false},
+		{"the firmware default", `{"shoulder": {"min": 250, "max": 700}}`, map[string]Limit{"shoulder": shoulder}, false},
+		{"unknown joint", `{"knee": {"min": 0, "max": 1}}`, nil, true},
+		{"min above max", `{"base": {"min": 600, "max": 400, "default": 500}}`, nil, true},
+		{"beyond the firmware", `{"elbow": {"min": 100, "max": 800}}`, nil, true},
+		{"default out of the range", `{"base": {"min": 0, "max": 400}}`, nil, true},
+		{"not JSON", `{`, nil, true},
+	}
+	for i, tt := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("limits%d.json", i))
+		if err := ioutil.WriteFile(path, []byte(tt.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		limits, err := LoadLimits(path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: LoadLimits() error = %v, want an error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(limits) != len(FirmwareLimits) {
+			t.Errorf("%v: LoadLimits() returned %v limits, want %v", tt.name, len(limits), len(FirmwareLimits))
+			continue
+		}
+		// in the order of the firmware, the joints missing keeping its limits
+		for i, l := range limits {
+			want, ok := tt.want[l.Name]
+			if !ok {
+				want = FirmwareLimits[i]
+			}
+			if l != want || l.Name != FirmwareLimits[i].Name {
+				t.Errorf("%v: limit %v = %+v, want %+v", tt.name, i, l, want)
+			}
+		}
+	}
+	if _, err := LoadLimits(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("LoadLimits() of a missing file = nil, want an error")
+	}
+}
+
+func TestSetJointLimits(t *testing.T) {
+	defer SetJointLimits(FirmwareLimits)
+	tests := []struct {
+		name   string
+		limits map[string]Limit
+		home   JointPosition
+		rest   JointPosition
+	}{
+		{"the firmware", nil, JointPosition{512, 400, 400, 580, 512, 128}, SleepPosition},
+		{"a narrower shoulder and elbow", map[string]Limit{
+			"shoulder": {Name: "shoulder", Min: 250, Max: 700, Default: 450},
+			"elbow":    {Name: "elbow", Min: 300, Max: 800, Default: 350},
+		}, JointPosition{512, 450, 350, 580, 512, 128}, JointPosition{512, 250, 300, 512, 512, 256}},
+		{"a narrower gripper", map[string]Limit{
+			"gripper": {Name: "gripper", Min: 0, Max: 200, Default: 100},
+		}, JointPosition{512, 400, 400, 580, 512, 100}, JointPosition{512, 205, 210, 512, 512, 200}},
+	}
+	for _, tt := range tests {
+		limits := append([]Limit{}, FirmwareLimits...)
+		for i, l := range limits {
+			if narrower, ok := tt.limits[l.Name]; ok {
+				limits[i] = narrower
+			}
+		}
+		SetJointLimits(limits)
+		// the home and the rest poses follow the limits
+		if home := HomePosition(); home != tt.home {
+			t.Errorf("%v: HomePosition() = %+v, want %+v", tt.name, home, tt.home)
+		}
+		rest := RestPosition()
+		if rest != tt.rest {
+			t.Errorf("%v: RestPosition() = %+v, want %+v", tt.name, rest, tt.rest)
+		}
+		if err := rest.Validate(); err != nil {
+			t.Errorf("%v: RestPosition().Validate() = %v", tt.name, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		alp     *ArmLinkPacket
+		mode    Mode
+		wantErr string
+	}{
+		{"home", NewArmLinkPacket(512, 400, 400, 580, 512, 128, 128, 0, 0), ModeBackhoe, ""},
+		{"on the limits", NewArmLinkPacket(0, 205, 900, 830, 1023, 512, 254, 127, 0), ModeBackhoe, ""},
+		{"shoulder below", NewArmLinkPacket(512, 204, 400, 580, 512, 128, 128, 0, 0), ModeBackhoe, "shoulder"},
+		{"gripper above", NewArmLinkPacket(512, 400, 400, 580, 512, 513, 128, 0, 0), ModeBackhoe, "gripper"},
+		{"delta above", NewArmLinkPacket(512, 400, 400, 580, 512, 128, 255, 0, 0), ModeBackhoe, "delta"},
+		{"button above", NewArmLinkPacket(512, 400, 400, 580, 512, 128, 128, 128, 0), ModeBackhoe, "button"},
+		{"positions ignored with an extended instruction", NewArmLinkPacket(0, 0, 0, 0, 0, 0, 0, 0, ExtendedStop), ModeBackhoe, ""},
+		{"extended above", NewArmLinkPacket(0, 0, 0, 0, 0, 0, 0, 0, 255), ModeBackhoe, "extended"},
+		{"x with its offset", NewArmLinkPacket(512-300, 200, 200, 90, 512, 128, 128, 0, 0), ModeCartesianStraight, ""},
+		{"x beyond", NewArmLinkPacket(512+301, 200, 200, 90, 512, 128, 128, 0, 0), ModeCartesianStraight, "x"},
+		{"wrist angle with its offset", NewArmLinkPacket(512, 200, 200, 90-31, 512, 128, 128, 0, 0), ModeCartesian90, "wristAngle"},
+		{"base in cylindrical", NewArmLinkPacket(1023, 200, 200, 90+30, 512, 128, 128, 0, 0), ModeCylindricalStraight, ""},
+		{"y below in cylindrical", NewArmLinkPacket(512, 49, 200, 90, 512, 128, 128, 0, 0), ModeCylindrical90, "y"},
+	}
+	for _, tt := range tests {
+		err := tt.alp.Validate(tt.mode)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%v: Validate() = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		re, ok := err.(*RangeError)
+		if !ok || re.Limit.Name != tt.wantErr || re.Mode != tt.mode {
+			t.Errorf("%v: Validate() = %v, want a RangeError of %v in %v mode", tt.name, err, tt.wantErr, tt.mode)
+		}
+	}
+}
+
+func TestJointPositionValidate(t *testing.T) {
+	defer SetJointLimits(FirmwareLimits)
+	limits := append([]Limit{}, FirmwareLimits...)
+	limits[1] = Limit{Name: "shoulder", Min: 250, Max: 700, Default: 400}
+	tests := []struct {
+		name    string
+		limits  []Limit
+		jp      JointPosition
+		wantErr string
+	}{
+		{"home", FirmwareLimits, HomePosition(), ""},
+		{"wrist angle below", FirmwareLimits, JointPosition{512, 400, 400, 199, 512, 128}, "wristAngle"},
+		{"within the firmware only", limits, JointPosition{512, 210, 400, 580, 512, 128}, "shoulder"},
+		{"within the narrower limits", limits, JointPosition{512, 250, 400, 580, 512, 128}, ""},
+	}
+	for _, tt := range tests {
+		SetJointLimits(tt.limits)
+		err := tt.jp.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%v: Validate() = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		if re, ok := err.(*RangeError); !ok || re.Limit.Name != tt.wantErr {
+			t.Errorf("%v: Validate() = %v, want a RangeError of %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
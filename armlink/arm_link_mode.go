@@ -0,0 +1,98 @@
+package armlink
+
+import (
+	"fmt"
+)
+
+// Mode is the positioning mode reported by the firmware
+type Mode byte
+
+// Modes reported by the firmware
+const (
+	ModeUnknown             Mode = 0
+	ModeCartesianStraight   Mode = 1
+	ModeCartesian90         Mode = 2
+	ModeCylindricalStraight Mode = 3
+	ModeCylindrical90       Mode = 4
+	ModeBackhoe             Mode = 5
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeCartesianStraight:
+		return "3D Cartesian / Straight Wrist"
+	case ModeCartesian90:
+		return "3D Cartesian / 90° Wrist"
+	case ModeCylindricalStraight:
+		return "3D Cylindrical / Straight Wrist"
+	case ModeCylindrical90:
+		return "3D Cylindrical / 90° Wrist"
+	case ModeBackhoe:
+		return "Backhoe/Joint"
+	}
+	return fmt.Sprintf("Unknown(%d)", byte(m))
+}
+
+// modeNames are the names to refer the modes
+var modeNames = map[Mode]string{
+	ModeCartesianStraight:   "cartesian",
+	ModeCartesian90:         "cartesian90",
+	ModeCylindricalStraight: "cylindrical",
+	ModeCylindrical90:       "cylindrical90",
+	ModeBackhoe:             "backhoe",
+}
+
+// Name returns the short name of the Mode, e.g. "cartesian90"
+func (m Mode) Name() string {
+	if name, ok := modeNames[m]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseMode returns the Mode for the short name
+func ParseMode(name string) (Mode, error) {
+	for m, n := range modeNames {
+		if n == name {
+			return m, nil
+		}
+	}
+	return ModeUnknown, fmt.Errorf("armlink: unknown mode %q", name)
+}
+
+// Extended returns the extended instruction changing to the Mode, 0 for ModeUnknown
+func (m Mode) Extended() byte {
+	switch m {
+	case ModeCartesianStraight:
+		return ExtendedCartesianStraight
+	case ModeCartesian90:
+		return ExtendedCartesian90
+	case ModeCylindricalStraight:
+		return ExtendedCylindricalStraight
+	case ModeCylindrical90:
+		return ExtendedCylindrical90
+	case ModeBackhoe:
+		return ExtendedReset
+	}
+	return 0
+}
+
+// IsCartesian reports if the Mode is one of the 3D Cartesian modes
+func (m Mode) IsCartesian() bool {
+	return m == ModeCartesianStraight || m == ModeCartesian90
+}
+
+// IsCylindrical reports if the Mode is one of the 3D Cylindrical modes
+func (m Mode) IsCylindrical() bool {
+	return m == ModeCylindricalStraight || m == ModeCylindrical90
+}
+
+// ModeForExtended returns the Mode the extended instruction changes to, false if it is not a mode change
+func ModeForExtended(e byte) (Mode, bool) {
+	for m := range modeNames {
+		if m.Extended() == e {
+			return m, true
+		}
+	}
+	return ModeUnknown, false
+}
@@ -4,10 +4,33 @@ import (
 	"fmt"
 )
 
-var (
-	ExtendedStop  = byte(17)
-	ExtendedReset = byte(64)
-	ExtendedSleep = byte(96)
+const (
+	// ArmLinkPacketSize is the length of an ArmLink frame in bytes
+	ArmLinkPacketSize = 17
+
+	armLinkHeader = byte(0xff)
+)
+
+// Extended instructions
+const (
+	// ExtendedStop stops the arm immediately
+	ExtendedStop byte = 17
+	// ExtendedCartesianStraight changes Mode to 3D Cartesian / Straight Wrist & Go to Home
+	ExtendedCartesianStraight byte = 32
+	// ExtendedCartesian90 changes Mode to 3D Cartesian / 90° Wrist & Go to Home
+	ExtendedCartesian90 byte = 40
+	// ExtendedCylindricalStraight changes Mode to 3D Cylindrical / Straight Wrist & Go to Home
+	ExtendedCylindricalStraight byte = 48
+	// ExtendedCylindrical90 changes Mode to 3D Cylindrical / 90° Wrist & Go to Home
+	ExtendedCylindrical90 byte = 56
+	// ExtendedReset changes Mode to Backhoe/Joint & Go to Home
+	ExtendedReset byte = 64
+	// ExtendedCenter centers all the servos
+	ExtendedCenter byte = 80
+	// ExtendedSleep puts the arm in sleep mode
+	ExtendedSleep byte = 96
+	// ExtendedArmID requests the arm to report its ID and Mode
+	ExtendedArmID byte = 112
 )
 
 // ArmLinkPacket holds the armlink packet parameters
@@ -48,53 +71,81 @@ func NewArmLinkPacket(br, sr, er, wa, wr, g uint16, d, b, e byte) *ArmLinkPacket
 	return alp
 }
 
+// BaseRotation returns the base rotation
+func (alp *ArmLinkPacket) BaseRotation() uint16 {
+	return alp.baseRotation
+}
+
+// ShoulderRotation returns the shoulder rotation
+func (alp *ArmLinkPacket) ShoulderRotation() uint16 {
+	return alp.shoulderRotation
+}
+
+// ElbowRotation returns the elbow rotation
+func (alp *ArmLinkPacket) ElbowRotation() uint16 {
+	return alp.elbowRotation
+}
+
+// WristAngle returns the wrist angle
+func (alp *ArmLinkPacket) WristAngle() uint16 {
+	return alp.wristAngle
+}
+
+// WristRotation returns the wrist rotation
+func (alp *ArmLinkPacket) WristRotation() uint16 {
+	return alp.wristRotation
+}
+
+// Gripper returns the gripper
+func (alp *ArmLinkPacket) Gripper() uint16 {
+	return alp.gripper
+}
+
+// Delta returns the deltaByte
+func (alp *ArmLinkPacket) Delta() byte {
+	return alp.deltaByte
+}
+
+// Button returns the buttonByte
+func (alp *ArmLinkPacket) Button() byte {
+	return alp.buttonByte
+}
+
+// Extended returns the extendedInstructionByte
+func (alp *ArmLinkPacket) Extended() byte {
+	return alp.extendedInstructionByte
+}
+
+// Bytes encodes the ArmLinkPacket into a frame
 func (alp *ArmLinkPacket) Bytes() []byte {
-	baseRotationHighByte := byte((alp.baseRotation / 256) % 256)
-	baseRotationLowByte := byte(alp.baseRotation % 256)
-	shoulderRotationHighByte := byte((alp.shoulderRotation / 256) % 256)
-	shoulderRotationLowByte := byte(alp.shoulderRotation % 256)
-	elbowRotationHighByte := byte((alp.elbowRotation / 256) % 256)
-	elbowRotationLowByte := byte(alp.elbowRotation % 256)
-	wristAngleHighByte := byte((alp.wristAngle / 256) % 256)
-	wristAngleLowByte := byte(alp.wristAngle % 256)
-	wristRotationHighByte := byte((alp.wristRotation / 256) % 256)
-	wristRotationLowByte := byte(alp.wristRotation % 256)
-	gripperHighByte := byte((alp.gripper / 256) % 256)
-	gripperLowByte := byte(alp.gripper % 256)
-
-	checksum := ^((baseRotationHighByte +
-		baseRotationLowByte +
-		shoulderRotationHighByte +
-		shoulderRotationLowByte +
-		elbowRotationHighByte +
-		elbowRotationLowByte +
-		wristAngleHighByte +
-		wristAngleLowByte +
-		wristRotationHighByte +
-		wristRotationLowByte +
-		gripperHighByte +
-		gripperLowByte +
-		alp.deltaByte +
-		alp.buttonByte +
-		alp.extendedInstructionByte) % 0xff)
-
-	return []byte{
-		byte(0xff), // header
-		baseRotationHighByte,
-		baseRotationLowByte,
-		shoulderRotationHighByte,
-		shoulderRotationLowByte,
-		elbowRotationHighByte,
-		elbowRotationLowByte,
-		wristAngleHighByte,
-		wristAngleLowByte,
-		wristRotationHighByte,
-		wristRotationLowByte,
-		gripperHighByte,
-		gripperLowByte,
+	b := []byte{
+		armLinkHeader,
+		byte((alp.baseRotation / 256) % 256),
+		byte(alp.baseRotation % 256),
+		byte((alp.shoulderRotation / 256) % 256),
+		byte(alp.shoulderRotation % 256),
+		byte((alp.elbowRotation / 256) % 256),
+		byte(alp.elbowRotation % 256),
+		byte((alp.wristAngle / 256) % 256),
+		byte(alp.wristAngle % 256),
+		byte((alp.wristRotation / 256) % 256),
+		byte(alp.wristRotation % 256),
+		byte((alp.gripper / 256) % 256),
+		byte(alp.gripper % 256),
 		alp.deltaByte,
 		alp.buttonByte,
 		alp.extendedInstructionByte,
-		checksum,
+		0, // checksum
+	}
+	b[ArmLinkPacketSize-1] = checksum(b[1 : ArmLinkPacketSize-1])
+	return b
+}
+
+// checksum computes the ArmLink checksum of the payload bytes
+func checksum(payload []byte) byte {
+	var sum byte
+	for _, v := range payload {
+		sum += v
 	}
+	return ^(sum % 0xff)
 }
@@ -0,0 +1,93 @@
+package armlink
+
+import (
+	"fmt"
+)
+
+// ShortPacketError is returned when a frame has less bytes than expected
+type ShortPacketError struct {
+	Length   int
+	Expected int
+}
+
+func (e *ShortPacketError) Error() string {
+	return fmt.Sprintf("armlink: short packet of %v bytes, expected %v", e.Length, e.Expected)
+}
+
+// HeaderError is returned when a frame does not start with the header byte
+type HeaderError struct {
+	Header byte
+}
+
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("armlink: invalid header %#02x", e.Header)
+}
+
+// ChecksumError is returned when the checksum of a frame does not match its payload
+type ChecksumError struct {
+	Checksum byte
+	Expected byte
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("armlink: checksum %#02x, expected %#02x", e.Checksum, e.Expected)
+}
+
+// ParseArmLinkPacket decodes the first ArmLinkPacketSize bytes of b into an ArmLinkPacket
+func ParseArmLinkPacket(b []byte) (*ArmLinkPacket, error) {
+	if len(b) < ArmLinkPacketSize {
+		return nil, &ShortPacketError{Length: len(b), Expected: ArmLinkPacketSize}
+	}
+	if b[0] != armLinkHeader {
+		return nil, &HeaderError{Header: b[0]}
+	}
+	if cs := checksum(b[1 : ArmLinkPacketSize-1]); b[ArmLinkPacketSize-1] != cs {
+		return nil, &ChecksumError{Checksum: b[ArmLinkPacketSize-1], Expected: cs}
+	}
+	return NewArmLinkPacket(
+		uint16(b[1])<<8|uint16(b[2]),   // baseRotation
+		uint16(b[3])<<8|uint16(b[4]),   // shoulderRotation
+		uint16(b[5])<<8|uint16(b[6]),   // elbowRotation
+		uint16(b[7])<<8|uint16(b[8]),   // wristAngle
+		uint16(b[9])<<8|uint16(b[10]),  // wristRotation
+		uint16(b[11])<<8|uint16(b[12]), // gripper
+		b[13],                          // deltaByte
+		b[14],                          // buttonByte
+		b[15],                          // extendedInstructionByte
+	), nil
+}
+
+// ScanArmLinkPackets is a bufio.SplitFunc returning each valid ArmLink frame in a byte stream
+// The bytes before a header and the frames failing the validation are skipped
+// so that the scanner resynchronizes on the next 0xff in a noisy stream
+func ScanArmLinkPackets(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	return scanFrames(data, atEOF, ArmLinkPacketSize, func(frame []byte) error {
+		_, err := ParseArmLinkPacket(frame)
+		return err
+	})
+}
+
+// scanFrames splits the first frame of the size passing the validate out of data
+func scanFrames(data []byte, atEOF bool, size int, validate func([]byte) error) (advance int, token []byte, err error) {
+	for start := 0; start < len(data); start++ {
+		if data[start] != armLinkHeader {
+			continue
+		}
+		if len(data)-start < size {
+			if atEOF {
+				// the stream ended in the middle of a frame
+				return len(data), nil, nil
+			}
+			// wait for the rest of the frame
+			return start, nil, nil
+		}
+		frame := data[start : start+size]
+		if validate(frame) != nil {
+			// not a frame, look for the next header
+			continue
+		}
+		return start + size, frame, nil
+	}
+	// no header in data
+	return len(data), nil, nil
+}
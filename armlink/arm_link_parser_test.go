@@ -0,0 +1,129 @@
+package armlink
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestParseArmLinkPacket(t *testing.T) {
+	alp := NewArmLinkPacket(512, 400, 400, 580, 512, 128, 128, 0, 0)
+	valid := alp.Bytes()
+	badHeader := append([]byte{}, valid...)
+	badHeader[0] = 0xfe
+	badChecksum := append([]byte{}, valid...)
+	badChecksum[ArmLinkPacketSize-1]++
+	tests := []struct {
+		name    string
+		b       []byte
+		wantErr interface{}
+	}{
+		{"valid", valid, nil},
+		{"valid with trailing bytes", append(append([]byte{}, valid...), 0xff, 0x01), nil},
+		{"short", valid[:ArmLinkPacketSize-1], &ShortPacketError{}},
+		{"invalid header", badHeader, &HeaderError{}},
+		{"invalid checksum", badChecksum, &ChecksumError{}},
+	}
+	for _, tt := range tests {
+		got, err := ParseArmLinkPacket(tt.b)
+		switch tt.wantErr.(type) {
+		case nil:
+			if err != nil {
+				t.Errorf("%v: ParseArmLinkPacket() error = %v", tt.name, err)
+			} else if *got != *alp {
+				t.Errorf("%v: ParseArmLinkPacket() = %v, want %v", tt.name, got, alp)
+			}
+		case *ShortPacketError:
+			if _, ok := err.(*ShortPacketError); !ok {
+				t.Errorf("%v: ParseArmLinkPacket() error = %v, want a ShortPacketError", tt.name, err)
+			}
+		case *HeaderError:
+			if _, ok := err.(*HeaderError); !ok {
+				t.Errorf("%v: ParseArmLinkPacket() error = %v, want a HeaderError", tt.name, err)
+			}
+		case *ChecksumError:
+			if _, ok := err.(*ChecksumError); !ok {
+				t.Errorf("%v: ParseArmLinkPacket() error = %v, want a ChecksumError", tt.name, err)
+			}
+		}
+	}
+}
+
+func TestScanArmLinkPackets(t *testing.T) {
+	home := NewArmLinkPacket(512, 400, 400, 580, 512, 128, 128, 0, 0).Bytes()
+	// 1023 is sent as 0x03 0xff, a header byte within the frame
+	edge := NewArmLinkPacket(1023, 400, 400, 580, 0, 128, 128, 0, 0).Bytes()
+	corrupted := append([]byte{}, home...)
+	corrupted[5] ^= 0x10
+	corruptedEdge := append([]byte{}, edge...)
+	corruptedEdge[6] ^= 0x10
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	tests := []struct {
+		name   string
+		stream []byte
+		want   [][]byte
+	}{
+		{"frames back to back", join(home, edge), [][]byte{home, edge}},
+		{"noise before a frame", join([]byte{0x00, 0x42, 0x13}, home), [][]byte{home}},
+		{"a header in the noise", join([]byte{0xff, 0x01, 0x02}, home), [][]byte{home}},
+		{"a header within a frame", join([]byte{0x07}, edge, home), [][]byte{edge, home}},
+		{"a header within a corrupted frame", join(corruptedEdge, home), [][]byte{home}},
+		{"a corrupted frame", join(corrupted, home), [][]byte{home}},
+		{"the last frame cut", join(home, edge[:10]), [][]byte{home}},
+		{"no frame", []byte{0x01, 0x02, 0x03}, nil},
+	}
+	for _, tt := range tests {
+		scanner := bufio.NewScanner(bytes.NewReader(tt.stream))
+		scanner.Split(ScanArmLinkPackets)
+		var got [][]byte
+		for scanner.Scan() {
+			got = append(got, append([]byte{}, scanner.Bytes()...))
+		}
+		if err := scanner.Err(); err != nil {
+			t.Errorf("%v: scanner error = %v", tt.name, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%v: scanned %v frames, want %v", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			if !bytes.Equal(got[i], tt.want[i]) {
+				t.Errorf("%v: frame %v = % x, want % x", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestScanArmLinkResponses(t *testing.T) {
+	alr := &ArmLinkResponse{ArmID: ArmIDReactor, Mode: ModeBackhoe}
+	valid := alr.Bytes()
+	corrupted := append([]byte{}, valid...)
+	corrupted[ArmLinkResponseSize-1]++
+	tests := []struct {
+		name   string
+		stream []byte
+		want   int
+	}{
+		{"one response", valid, 1},
+		{"noise and a corrupted response before", bytes.Join([][]byte{{0x13}, corrupted, valid}, nil), 1},
+		{"cut", valid[:3], 0},
+	}
+	for _, tt := range tests {
+		scanner := bufio.NewScanner(bytes.NewReader(tt.stream))
+		scanner.Split(ScanArmLinkResponses)
+		got := 0
+		for scanner.Scan() {
+			parsed, err := ParseArmLinkResponse(scanner.Bytes())
+			if err != nil || *parsed != *alr {
+				t.Errorf("%v: ParseArmLinkResponse() = %v, %v, want %v", tt.name, parsed, err, alr)
+			}
+			got++
+		}
+		if got != tt.want {
+			t.Errorf("%v: scanned %v responses, want %v", tt.name, got, tt.want)
+		}
+	}
+}
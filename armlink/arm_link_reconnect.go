@@ -0,0 +1,177 @@
+package armlink
+
+import (
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrDisconnected is returned while the link to the arm is down
+var ErrDisconnected = errors.New("armlink: disconnected")
+
+// Backoff intervals for reopening the link
+var (
+	ReconnectMinInterval = 100 * time.Millisecond
+	ReconnectMaxInterval = 30 * time.Second
+)
+
+// dialFunc opens the port of a reconnectingPort
+type dialFunc func() (io.ReadWriteCloser, error)
+
+// reconnectingPort keeps a port open and reopens it with exponential backoff
+// whenever a read or a write fails
+type reconnectingPort struct {
+	name        string
+	dial        dialFunc
+	mu          sync.Mutex
+	port        io.ReadWriteCloser // nil while disconnected
+	connected   chan struct{}      // closed while connected
+	reconnected chan struct{}
+	closed      chan struct{}
+}
+
+// newReconnectingPort opens the port for the first time
+func newReconnectingPort(name string, dial dialFunc) (*reconnectingPort, error) {
+	port, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	rp := &reconnectingPort{
+		name:        name,
+		dial:        dial,
+		port:        port,
+		connected:   make(chan struct{}),
+		reconnected: make(chan struct{}, 1),
+		closed:      make(chan struct{}),
+	}
+	close(rp.connected)
+	return rp, nil
+}
+
+// Connected reports if the port is open
+func (rp *reconnectingPort) Connected() bool {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return rp.port != nil
+}
+
+// Reconnected returns the channel notified each time the port is reopened
+func (rp *reconnectingPort) Reconnected() <-chan struct{} {
+	return rp.reconnected
+}
+
+// Close closes the port and stops reconnecting
+func (rp *reconnectingPort) Close() error {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	select {
+	case <-rp.closed:
+		return nil
+	default:
+		close(rp.closed)
+	}
+	if rp.port == nil {
+		return nil
+	}
+	return rp.port.Close()
+}
+
+// Write writes to the port, ErrDisconnected while reconnecting
+func (rp *reconnectingPort) Write(b []byte) (int, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	if rp.port == nil {
+		return 0, ErrDisconnected
+	}
+	n, err := rp.port.Write(b)
+	if err != nil {
+		rp.disconnect(rp.port, err)
+	}
+	return n, err
+}
+
+// Read reads from the port, waiting for the reconnection if needed
+func (rp *reconnectingPort) Read(b []byte) (int, error) {
+	for {
+		rp.mu.Lock()
+		port, connected := rp.port, rp.connected
+		rp.mu.Unlock()
+		select {
+		case <-rp.closed:
+			return 0, io.EOF
+		case <-connected:
+		}
+		if port == nil {
+			continue
+		}
+		n, err := port.Read(b)
+		if err == nil {
+			return n, nil
+		}
+		select {
+		case <-rp.closed:
+			return n, io.EOF
+		default:
+		}
+		rp.mu.Lock()
+		rp.disconnect(port, err)
+		rp.mu.Unlock()
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// disconnect closes the failed port and starts reconnecting
+// It must be called with rp.mu held
+func (rp *reconnectingPort) disconnect(port io.ReadWriteCloser, err error) {
+	if rp.port != port {
+		// already reconnecting
+		return
+	}
+	log.Printf("[Transport] %v disconnected: %v", rp.name, err)
+	port.Close()
+	rp.port = nil
+	rp.connected = make(chan struct{})
+	go rp.reconnect()
+}
+
+// reconnect reopens the port with exponential backoff until it succeeds or the port is closed
+func (rp *reconnectingPort) reconnect() {
+	interval := ReconnectMinInterval
+	for {
+		select {
+		case <-rp.closed:
+			return
+		case <-time.After(interval):
+		}
+		port, err := rp.dial()
+		if err != nil {
+			interval *= 2
+			if interval > ReconnectMaxInterval {
+				interval = ReconnectMaxInterval
+			}
+			log.Printf("[Transport] Reconnecting %v failed, retrying in %v: %v", rp.name, interval, err)
+			continue
+		}
+		rp.mu.Lock()
+		select {
+		case <-rp.closed:
+			rp.mu.Unlock()
+			port.Close()
+			return
+		default:
+		}
+		rp.port = port
+		close(rp.connected)
+		rp.mu.Unlock()
+		log.Printf("[Transport] %v reconnected", rp.name)
+		select {
+		case rp.reconnected <- struct{}{}:
+		default:
+		}
+		return
+	}
+}
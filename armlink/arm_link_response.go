@@ -0,0 +1,75 @@
+package armlink
+
+import (
+	"fmt"
+)
+
+// ArmLinkResponseSize is the length of an ArmLink response frame in bytes
+const ArmLinkResponseSize = 5
+
+// Arm IDs reported by the firmware
+const (
+	ArmIDPincher = byte(1)
+	ArmIDReactor = byte(2)
+	ArmIDWidowX  = byte(3)
+)
+
+// ArmLinkResponse holds the response packet the firmware replies
+// to the ID request and to the mode changes
+type ArmLinkResponse struct {
+	ArmID byte
+	Mode  Mode
+	Error byte
+}
+
+func (alr *ArmLinkResponse) String() string {
+	return fmt.Sprintf("ArmID: %v, Mode: %v, Error: %#02x", alr.ArmID, alr.Mode, alr.Error)
+}
+
+// Bytes encodes the ArmLinkResponse into a frame
+func (alr *ArmLinkResponse) Bytes() []byte {
+	b := []byte{
+		armLinkHeader,
+		alr.ArmID,
+		byte(alr.Mode),
+		alr.Error,
+		0, // checksum
+	}
+	b[ArmLinkResponseSize-1] = responseChecksum(b[1 : ArmLinkResponseSize-1])
+	return b
+}
+
+// responseChecksum computes the checksum of the response payload bytes
+func responseChecksum(payload []byte) byte {
+	var sum byte
+	for _, v := range payload {
+		sum += v
+	}
+	return ^sum
+}
+
+// ParseArmLinkResponse decodes the first ArmLinkResponseSize bytes of b into an ArmLinkResponse
+func ParseArmLinkResponse(b []byte) (*ArmLinkResponse, error) {
+	if len(b) < ArmLinkResponseSize {
+		return nil, &ShortPacketError{Length: len(b), Expected: ArmLinkResponseSize}
+	}
+	if b[0] != armLinkHeader {
+		return nil, &HeaderError{Header: b[0]}
+	}
+	if cs := responseChecksum(b[1 : ArmLinkResponseSize-1]); b[ArmLinkResponseSize-1] != cs {
+		return nil, &ChecksumError{Checksum: b[ArmLinkResponseSize-1], Expected: cs}
+	}
+	return &ArmLinkResponse{
+		ArmID: b[1],
+		Mode:  Mode(b[2]),
+		Error: b[3],
+	}, nil
+}
+
+// ScanArmLinkResponses is a bufio.SplitFunc returning each valid response frame in a byte stream
+func ScanArmLinkResponses(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	return scanFrames(data, atEOF, ArmLinkResponseSize, func(frame []byte) error {
+		_, err := ParseArmLinkResponse(frame)
+		return err
+	})
+}
@@ -2,46 +2,125 @@ package armlink
 
 import (
 	"encoding/hex"
+	"fmt"
 	"io"
 	"log"
+	"os"
 
 	"github.com/jacobsa/go-serial/serial"
 )
 
+// SerialConfig holds the options to open the serial port
+type SerialConfig struct {
+	PortName string
+	BaudRate uint
+	DataBits uint
+	StopBits uint
+	// InterCharacterTimeout in milliseconds, a multiple of 100 up to 25500
+	InterCharacterTimeout uint
+	// MinimumReadSize in bytes before a read returns
+	MinimumReadSize uint
+	// ReadTimeout in milliseconds, a multiple of 100 up to 25500, after which a read returns without any byte
+	// so an unplugged cable is noticed, over the InterCharacterTimeout and the MinimumReadSize; 0 to wait for them
+	ReadTimeout uint
+}
+
+// DefaultSerialConfig is the configuration of the FTDI-USB cable with the ArmLink firmware
+var DefaultSerialConfig = SerialConfig{
+	PortName:        "/dev/ttyUSB0",
+	BaudRate:        38400,
+	DataBits:        8,
+	StopBits:        1,
+	MinimumReadSize: 4,
+}
+
+// ArmLinkSerial is the Transport over the FTDI-USB serial port
+// It reopens the port when the cable is unplugged
 type ArmLinkSerial struct {
-	port io.ReadWriteCloser
+	port *reconnectingPort
 }
 
-func NewArmLinkSerial() *ArmLinkSerial {
+// NewArmLinkSerial opens the serial port and creates a new ArmLinkSerial
+func NewArmLinkSerial(config SerialConfig) (*ArmLinkSerial, error) {
 	als := &ArmLinkSerial{}
 
 	// Set up options.
 	options := serial.OpenOptions{
-		PortName:        "/dev/ttyUSB0",
-		BaudRate:        38400,
-		DataBits:        8,
-		StopBits:        1,
-		MinimumReadSize: 4,
+		PortName:              config.PortName,
+		BaudRate:              config.BaudRate,
+		DataBits:              config.DataBits,
+		StopBits:              config.StopBits,
+		InterCharacterTimeout: config.InterCharacterTimeout,
+		MinimumReadSize:       config.MinimumReadSize,
+	}
+	if config.ReadTimeout != 0 {
+		options.InterCharacterTimeout = config.ReadTimeout
+		options.MinimumReadSize = 0
 	}
 
 	// Open the port.
-	port, err := serial.Open(options)
+	port, err := newReconnectingPort(config.PortName, func() (io.ReadWriteCloser, error) {
+		port, err := serial.Open(options)
+		if err != nil {
+			return nil, fmt.Errorf("serial.Open %v: %v", config.PortName, err)
+		}
+		return &serialPort{ReadWriteCloser: port, name: config.PortName}, nil
+	})
 	if err != nil {
-		log.Fatalf("serial.Open: %v", err)
+		return nil, err
 	}
 	als.port = port
 
-	return als
+	return als, nil
+}
+
+// Close closes the serial port
+func (als *ArmLinkSerial) Close() error {
+	return als.port.Close()
+}
+
+// Connected reports if the serial port is open
+func (als *ArmLinkSerial) Connected() bool {
+	return als.port.Connected()
+}
+
+// Reconnected returns the channel notified each time the serial port is reopened
+func (als *ArmLinkSerial) Reconnected() <-chan struct{} {
+	return als.port.Reconnected()
 }
 
-func (als *ArmLinkSerial) Close() {
-	als.port.Close()
+// Receive reads the bytes from the serial port
+func (als *ArmLinkSerial) Receive(b []byte) (int, error) {
+	return als.port.Read(b)
 }
 
-func (als *ArmLinkSerial) Send(b []byte) {
+// Send writes the bytes to the serial port
+func (als *ArmLinkSerial) Send(b []byte) error {
 	log.Println(hex.Dump(b))
 	_, err := als.port.Write(b)
 	if err != nil {
-		log.Fatalf("port.Write: %v", err)
+		return fmt.Errorf("port.Write: %v", err)
+	}
+	return nil
+}
+
+// serialPort retries the reads timed out without any byte
+// since the serial port reports them as io.EOF just like a hangup
+type serialPort struct {
+	io.ReadWriteCloser
+	name string
+}
+
+func (sp *serialPort) Read(b []byte) (int, error) {
+	for {
+		n, err := sp.ReadWriteCloser.Read(b)
+		if n == 0 && err == io.EOF {
+			// the device is gone when the cable is unplugged
+			if _, serr := os.Stat(sp.name); serr != nil {
+				return 0, serr
+			}
+			continue
+		}
+		return n, err
 	}
 }
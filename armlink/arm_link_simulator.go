@@ -0,0 +1,261 @@
+package armlink
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// the order of the joints in the ArmLink frame
+const (
+	jointBase = iota
+	jointShoulder
+	jointElbow
+	jointWristAngle
+	jointWristRotation
+	jointGripper
+	jointCount
+)
+
+var (
+	// simulatorHomePose is where the firmware goes on ExtendedReset
+	simulatorHomePose = HomePosition().joints()
+	// simulatorIKHomePose is the raw parameters of the home in the IK modes
+	simulatorIKHomePose = [jointCount]uint16{512, 200, 200, 90, 512, 256}
+	// simulatorSleepPose is where the firmware rests on ExtendedSleep
+	simulatorSleepPose = SleepPosition.joints()
+)
+
+// simulatedJoint interpolates a servo from one position to the other
+type simulatedJoint struct {
+	from     uint16
+	to       uint16
+	start    time.Time
+	duration time.Duration
+}
+
+// position returns the position of the joint at t
+func (sj *simulatedJoint) position(t time.Time) uint16 {
+	elapsed := t.Sub(sj.start)
+	if sj.duration <= 0 || elapsed >= sj.duration {
+		return sj.to
+	}
+	if elapsed <= 0 {
+		return sj.from
+	}
+	progress := float64(elapsed) / float64(sj.duration)
+	return uint16(float64(sj.from) + (float64(sj.to)-float64(sj.from))*progress + 0.5)
+}
+
+// moving reports if the joint is still on its way at t
+func (sj *simulatedJoint) moving(t time.Time) bool {
+	return sj.position(t) != sj.to
+}
+
+// SimulatorState is a snapshot of the virtual arm
+type SimulatorState struct {
+	Base          uint16
+	Shoulder      uint16
+	Elbow         uint16
+	WristAngle    uint16
+	WristRotation uint16
+	Gripper       uint16
+	Mode          Mode
+	Moving        bool
+	Asleep        bool
+	Stopped       bool
+	Frames        int
+	Errors        int
+}
+
+// String returns a string rep for the state
+func (ss SimulatorState) String() string {
+	return fmt.Sprintf("Base: %v, Shoulder: %v, Elbow: %v, WristAngle: %v, WristRotation: %v, Gripper: %v, Mode: %v, Moving: %v, Asleep: %v, Stopped: %v", ss.Base, ss.Shoulder, ss.Elbow, ss.WristAngle, ss.WristRotation, ss.Gripper, ss.Mode, ss.Moving, ss.Asleep, ss.Stopped)
+}
+
+// Simulator is a Transport emulating the Reactor arm running the ArmLink firmware
+// The simulator does not solve the kinematics, in the IK modes the joints hold
+// the raw parameters of the frames instead of the servo positions
+type Simulator struct {
+	mu      sync.Mutex
+	joints  [jointCount]simulatedJoint
+	mode    Mode
+	asleep  bool
+	stopped bool
+	frames  int
+	errors  int
+	replies chan []byte
+	pending []byte
+	closed  chan struct{}
+	now     func() time.Time
+}
+
+// NewSimulator creates a new Simulator resting in the sleep pose
+func NewSimulator() *Simulator {
+	sim := &Simulator{
+		mode:    ModeBackhoe,
+		asleep:  true,
+		replies: make(chan []byte, 16),
+		closed:  make(chan struct{}),
+		now:     time.Now,
+	}
+	t := sim.now()
+	for i := range sim.joints {
+		sim.joints[i] = simulatedJoint{
+			from:  simulatorSleepPose[i],
+			to:    simulatorSleepPose[i],
+			start: t,
+		}
+	}
+	return sim
+}
+
+// Close stops the Simulator and unblocks Receive
+func (sim *Simulator) Close() error {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	select {
+	case <-sim.closed:
+	default:
+		close(sim.closed)
+	}
+	return nil
+}
+
+// Receive reads the response packets replied by the Simulator
+// It blocks until a reply is available or the Simulator is closed
+func (sim *Simulator) Receive(b []byte) (int, error) {
+	if len(sim.pending) == 0 {
+		select {
+		case sim.pending = <-sim.replies:
+		case <-sim.closed:
+			return 0, io.EOF
+		}
+	}
+	n := copy(b, sim.pending)
+	sim.pending = sim.pending[n:]
+	return n, nil
+}
+
+// reply queues an ArmLinkResponse with the current state
+func (sim *Simulator) reply() {
+	alr := &ArmLinkResponse{
+		ArmID: ArmIDReactor,
+		Mode:  sim.mode,
+	}
+	select {
+	case sim.replies <- alr.Bytes():
+	default:
+		// nobody is receiving, drop the reply
+	}
+}
+
+// Send consumes the ArmLink frames in b
+func (sim *Simulator) Send(b []byte) error {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	select {
+	case <-sim.closed:
+		return fmt.Errorf("simulator: closed")
+	default:
+	}
+	if len(b)%ArmLinkPacketSize != 0 {
+		sim.errors++
+		return fmt.Errorf("simulator: %v bytes is not a sequence of %v-byte frames", len(b), ArmLinkPacketSize)
+	}
+	for i := 0; i < len(b); i += ArmLinkPacketSize {
+		alp, err := ParseArmLinkPacket(b[i : i+ArmLinkPacketSize])
+		if err != nil {
+			sim.errors++
+			return fmt.Errorf("simulator: %v", err)
+		}
+		sim.frames++
+		sim.apply(alp)
+	}
+	return nil
+}
+
+// apply performs the instruction in a validated ArmLinkPacket
+func (sim *Simulator) apply(alp *ArmLinkPacket) {
+	t := sim.now()
+	switch alp.Extended() {
+	case ExtendedStop:
+		// freeze every joint where it is
+		for i := range sim.joints {
+			p := sim.joints[i].position(t)
+			sim.joints[i] = simulatedJoint{from: p, to: p, start: t}
+		}
+		sim.stopped = true
+		return
+	case ExtendedSleep:
+		sim.asleep = true
+		sim.moveTo(simulatorSleepPose, t, 0)
+		return
+	case ExtendedArmID:
+		sim.reply()
+		return
+	}
+	if mode, ok := ModeForExtended(alp.Extended()); ok {
+		// change the mode and go to home
+		sim.mode = mode
+		sim.asleep = false
+		sim.stopped = false
+		if mode == ModeBackhoe {
+			sim.moveTo(simulatorHomePose, t, 0)
+		} else {
+			sim.moveTo(simulatorIKHomePose, t, 0)
+		}
+		sim.reply()
+		return
+	}
+	target := [jointCount]uint16{
+		alp.BaseRotation(),
+		alp.ShoulderRotation(),
+		alp.ElbowRotation(),
+		alp.WristAngle(),
+		alp.WristRotation(),
+		alp.Gripper(),
+	}
+	sim.asleep = false
+	sim.stopped = false
+	sim.moveTo(target, t, alp.Delta())
+}
+
+// moveTo starts moving every joint toward the target
+// the firmware interpolates over delta*16 milliseconds
+func (sim *Simulator) moveTo(target [jointCount]uint16, t time.Time, delta byte) {
+	for i := range sim.joints {
+		sim.joints[i] = simulatedJoint{
+			from:     sim.joints[i].position(t),
+			to:       target[i],
+			start:    t,
+			duration: time.Duration(delta) * 16 * time.Millisecond,
+		}
+	}
+}
+
+// State returns the current state of the virtual arm
+func (sim *Simulator) State() SimulatorState {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	t := sim.now()
+	moving := false
+	for i := range sim.joints {
+		moving = moving || sim.joints[i].moving(t)
+	}
+	return SimulatorState{
+		Base:          sim.joints[jointBase].position(t),
+		Shoulder:      sim.joints[jointShoulder].position(t),
+		Elbow:         sim.joints[jointElbow].position(t),
+		WristAngle:    sim.joints[jointWristAngle].position(t),
+		WristRotation: sim.joints[jointWristRotation].position(t),
+		Gripper:       sim.joints[jointGripper].position(t),
+		Mode:          sim.mode,
+		Moving:        moving,
+		Asleep:        sim.asleep,
+		Stopped:       sim.stopped,
+		Frames:        sim.frames,
+		Errors:        sim.errors,
+	}
+}
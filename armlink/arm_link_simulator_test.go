@@ -0,0 +1,114 @@
+package armlink
+
+import (
+	"testing"
+	"time"
+)
+
+// simulatorStep sends the frame At after the start, or only reads the state without a frame
+type simulatorStep struct {
+	At    time.Duration
+	Frame []byte
+}
+
+// extended returns the frame of the extended instruction
+func extended(e byte) []byte {
+	alp := &ArmLinkPacket{}
+	alp.SetExtended(e)
+	return alp.Bytes()
+}
+
+// joints returns the frame moving the base and the shoulder over delta*16 ms
+func joints(base, shoulder uint16, delta byte) []byte {
+	return NewArmLinkPacket(base, shoulder, 400, 580, 512, 128, delta, 0, 0).Bytes()
+}
+
+func TestSimulator(t *testing.T) {
+	ms := time.Millisecond
+	sleep := SleepPosition
+	home := HomePosition()
+	tests := []struct {
+		name  string
+		steps []simulatorStep
+		at    time.Duration
+		want  SimulatorState
+	}{
+		{"asleep at the start", nil, 0,
+			SimulatorState{Base: sleep.Base, Shoulder: sleep.Shoulder, Mode: ModeBackhoe, Asleep: true}},
+		{"home on reset at once", []simulatorStep{{0, extended(ExtendedReset)}}, 0,
+			SimulatorState{Base: home.Base, Shoulder: home.Shoulder, Mode: ModeBackhoe, Frames: 1}},
+		{"halfway through a move", []simulatorStep{{0, extended(ExtendedReset)}, {0, joints(612, 500, 10)}}, 80 * ms,
+			SimulatorState{Base: 562, Shoulder: 450, Mode: ModeBackhoe, Moving: true, Frames: 2}},
+		{"at the end of a move", []simulatorStep{{0, extended(ExtendedReset)}, {0, joints(612, 500, 10)}}, 160 * ms,
+			SimulatorState{Base: 612, Shoulder: 500, Mode: ModeBackhoe, Frames: 2}},
+		{"a move taking over another", []simulatorStep{{0, extended(ExtendedReset)}, {0, joints(612, 500, 10)}, {80 * ms, joints(512, 400, 10)}}, 120 * ms,
+			SimulatorState{Base: 550, Shoulder: 438, Mode: ModeBackhoe, Moving: true, Frames: 3}},
+		{"frozen by the stop", []simulatorStep{{0, extended(ExtendedReset)}, {0, joints(612, 500, 10)}, {80 * ms, extended(ExtendedStop)}}, time.Second,
+			SimulatorState{Base: 562, Shoulder: 450, Mode: ModeBackhoe, Stopped: true, Frames: 3}},
+		{"moving again after the stop", []simulatorStep{{0, extended(ExtendedStop)}, {0, joints(612, 500, 0)}}, 0,
+			SimulatorState{Base: 612, Shoulder: 500, Mode: ModeBackhoe, Frames: 2}},
+		{"asleep again", []simulatorStep{{0, extended(ExtendedReset)}, {10 * ms, extended(ExtendedSleep)}}, 10 * ms,
+			SimulatorState{Base: sleep.Base, Shoulder: sleep.Shoulder, Mode: ModeBackhoe, Asleep: true, Frames: 2}},
+		{"the IK home in an IK mode", []simulatorStep{{0, extended(ExtendedCartesianStraight)}}, 0,
+			SimulatorState{Base: 512, Shoulder: 200, Mode: ModeCartesianStraight, Frames: 1}},
+		{"an invalid frame ignored", []simulatorStep{{0, extended(ExtendedReset)}, {0, joints(612, 500, 10)[:10]}}, 0,
+			SimulatorState{Base: home.Base, Shoulder: home.Shoulder, Mode: ModeBackhoe, Frames: 1, Errors: 1}},
+	}
+	for _, tt := range tests {
+		start := time.Now()
+		now := start
+		sim := NewSimulator()
+		sim.now = func() time.Time { return now }
+		for _, s := range tt.steps {
+			now = start.Add(s.At)
+			err := sim.Send(s.Frame)
+			if valid := len(s.Frame) == ArmLinkPacketSize; (err == nil) != valid {
+				t.Errorf("%v: Send(% x) = %v", tt.name, s.Frame, err)
+			}
+		}
+		now = start.Add(tt.at)
+		got := sim.State()
+		// the other joints are left out
+		got.Elbow, got.WristAngle, got.WristRotation, got.Gripper = 0, 0, 0, 0
+		if got != tt.want {
+			t.Errorf("%v: State() = %+v, want %+v", tt.name, got, tt.want)
+		}
+		sim.Close()
+	}
+}
+
+func TestSimulatorReplies(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+		want  *ArmLinkResponse
+	}{
+		{"ID request", extended(ExtendedArmID), &ArmLinkResponse{ArmID: ArmIDReactor, Mode: ModeBackhoe}},
+		{"mode change", extended(ExtendedCylindrical90), &ArmLinkResponse{ArmID: ArmIDReactor, Mode: ModeCylindrical90}},
+		{"move", joints(512, 400, 0), nil},
+	}
+	for _, tt := range tests {
+		sim := NewSimulator()
+		if err := sim.Send(tt.frame); err != nil {
+			t.Fatal(err)
+		}
+		if tt.want == nil {
+			if len(sim.replies) != 0 {
+				t.Errorf("%v: %v replies, want none", tt.name, len(sim.replies))
+			}
+			sim.Close()
+			continue
+		}
+		b := make([]byte, ArmLinkResponseSize)
+		n, err := sim.Receive(b)
+		sim.Close()
+		if err != nil {
+			t.Errorf("%v: Receive() error = %v", tt.name, err)
+			continue
+		}
+		alr, err := ParseArmLinkResponse(b[:n])
+		if err != nil || *alr != *tt.want {
+			t.Errorf("%v: Receive() = %v, %v, want %v", tt.name, alr, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package armlink
+
+// SpeedLimit is the speed of a move in the API, from the slowest 1 to the fastest 100
+var SpeedLimit = Limit{Name: "speed", Min: 1, Max: 100, Default: 50}
+
+// SpeedDelta returns the delta byte moving at the speed,
+// from DeltaLimit.Max at the slowest to DeltaLimit.Min at the fastest, the default speed being the default delta
+func SpeedDelta(speed int) byte {
+	switch {
+	case speed <= SpeedLimit.Min:
+		return byte(DeltaLimit.Max)
+	case speed >= SpeedLimit.Max:
+		return byte(DeltaLimit.Min)
+	}
+	span := DeltaLimit.Max - DeltaLimit.Min
+	return byte(DeltaLimit.Min + (span*(SpeedLimit.Max-speed)+(SpeedLimit.Max-SpeedLimit.Min)/2)/(SpeedLimit.Max-SpeedLimit.Min))
+}
@@ -0,0 +1,29 @@
+package armlink
+
+import "testing"
+
+func TestSpeedDelta(t *testing.T) {
+	tests := []struct {
+		name  string
+		speed int
+		want  byte
+	}{
+		{"slowest", SpeedLimit.Min, byte(DeltaLimit.Max)},
+		{"fastest", SpeedLimit.Max, byte(DeltaLimit.Min)},
+		{"default", SpeedLimit.Default, byte(DeltaLimit.Default)},
+		{"below the slowest", 0, byte(DeltaLimit.Max)},
+		{"above the fastest", 150, byte(DeltaLimit.Min)},
+		{"in between", 75, 64},
+	}
+	for _, tt := range tests {
+		if got := SpeedDelta(tt.speed); got != tt.want {
+			t.Errorf("%v: SpeedDelta(%v) = %v, want %v", tt.name, tt.speed, got, tt.want)
+		}
+	}
+	// a faster move never takes longer
+	for speed := SpeedLimit.Min; speed < SpeedLimit.Max; speed++ {
+		if SpeedDelta(speed+1) > SpeedDelta(speed) {
+			t.Errorf("SpeedDelta(%v) = %v is slower than SpeedDelta(%v) = %v", speed+1, SpeedDelta(speed+1), speed, SpeedDelta(speed))
+		}
+	}
+}
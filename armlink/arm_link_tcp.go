@@ -0,0 +1,81 @@
+package armlink
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"time"
+)
+
+// DialTimeout is the timeout to connect to the TCP bridge
+var DialTimeout = 5 * time.Second
+
+// ArmLinkTCP is the Transport over a TCP socket bridged to the serial port of the arm
+// e.g. by ser2net on a Raspberry Pi. It reconnects when the connection drops.
+type ArmLinkTCP struct {
+	port *reconnectingPort
+}
+
+// NewArmLinkTCP connects to the raw TCP bridge at the address and creates a new ArmLinkTCP
+func NewArmLinkTCP(address string) (*ArmLinkTCP, error) {
+	port, err := newReconnectingPort(address, func() (io.ReadWriteCloser, error) {
+		return net.DialTimeout("tcp", address, DialTimeout)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ArmLinkTCP{port: port}, nil
+}
+
+// NewArmLinkRFC2217 connects to the RFC 2217 (Telnet COM Port Control) bridge at the address,
+// configures its serial port with the config and creates a new ArmLinkTCP
+func NewArmLinkRFC2217(address string, config SerialConfig) (*ArmLinkTCP, error) {
+	port, err := newReconnectingPort(address, func() (io.ReadWriteCloser, error) {
+		conn, err := net.DialTimeout("tcp", address, DialTimeout)
+		if err != nil {
+			return nil, err
+		}
+		tc := newTelnetConn(conn)
+		if err := tc.configureComPort(config); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tc, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ArmLinkTCP{port: port}, nil
+}
+
+// Close closes the connection
+func (alt *ArmLinkTCP) Close() error {
+	return alt.port.Close()
+}
+
+// Connected reports if the connection is up
+func (alt *ArmLinkTCP) Connected() bool {
+	return alt.port.Connected()
+}
+
+// Reconnected returns the channel notified each time the connection is reestablished
+func (alt *ArmLinkTCP) Reconnected() <-chan struct{} {
+	return alt.port.Reconnected()
+}
+
+// Receive reads the bytes from the connection
+func (alt *ArmLinkTCP) Receive(b []byte) (int, error) {
+	return alt.port.Read(b)
+}
+
+// Send writes the bytes to the connection
+func (alt *ArmLinkTCP) Send(b []byte) error {
+	log.Println(hex.Dump(b))
+	_, err := alt.port.Write(b)
+	if err != nil {
+		return fmt.Errorf("conn.Write: %v", err)
+	}
+	return nil
+}
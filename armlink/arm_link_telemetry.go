@@ -0,0 +1,31 @@
+package armlink
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ServoState is the feedback read from a servo of the arm
+type ServoState struct {
+	ID          byte    `json:"id"`
+	Joint       string  `json:"joint"`
+	Position    uint16  `json:"position"`
+	Speed       int     `json:"speed"`
+	Load        int     `json:"load"`
+	Voltage     float64 `json:"voltage"`
+	Temperature int     `json:"temperature"`
+}
+
+func (ss ServoState) String() string {
+	return fmt.Sprintf("ID: %v, Joint: %v, Position: %v, Speed: %v, Load: %v, Voltage: %v, Temperature: %v", ss.ID, ss.Joint, ss.Position, ss.Speed, ss.Load, ss.Voltage, ss.Temperature)
+}
+
+// Telemeter is implemented by the Transports reading the state of the servos
+// The ArmLink firmware gives no feedback, only the backends talking to the servos do
+type Telemeter interface {
+	// Telemetry returns the last state read from each servo
+	Telemetry() ([]ServoState, error)
+}
+
+// ErrNoTelemetry is returned by the Transports wrapping one that is not a Telemeter
+var ErrNoTelemetry = errors.New("the transport reads no telemetry")
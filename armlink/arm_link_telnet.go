@@ -0,0 +1,203 @@
+package armlink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"sync"
+)
+
+// Telnet commands and options used by RFC 2217
+const (
+	telnetSE   = byte(240)
+	telnetSB   = byte(250)
+	telnetWILL = byte(251)
+	telnetWONT = byte(252)
+	telnetDO   = byte(253)
+	telnetDONT = byte(254)
+	telnetIAC  = byte(255)
+
+	telnetOptBinary  = byte(0)
+	telnetOptSGA     = byte(3)
+	telnetOptComPort = byte(44)
+
+	comPortSetBaudRate = byte(1)
+	comPortSetDataSize = byte(2)
+	comPortSetParity   = byte(3)
+	comPortSetStopSize = byte(4)
+
+	comPortParityNone = byte(1)
+)
+
+// the states of the telnet stream parser
+const (
+	telnetStateData = iota
+	telnetStateIAC
+	telnetStateOption
+	telnetStateSB
+	telnetStateSBIAC
+)
+
+// telnetConn is a net.Conn speaking the Telnet protocol with the RFC 2217 COM Port Control option
+// The data bytes are escaped and the negotiations are answered transparently
+type telnetConn struct {
+	net.Conn
+	writeMutex sync.Mutex
+	state      int
+	command    byte
+	will       map[byte]bool
+	do         map[byte]bool
+}
+
+// newTelnetConn wraps the conn in a telnetConn
+func newTelnetConn(conn net.Conn) *telnetConn {
+	return &telnetConn{
+		Conn: conn,
+		will: map[byte]bool{},
+		do:   map[byte]bool{},
+	}
+}
+
+// configureComPort negotiates the binary transmission and sets the serial port parameters
+func (tc *telnetConn) configureComPort(config SerialConfig) error {
+	tc.will[telnetOptBinary] = true
+	tc.do[telnetOptBinary] = true
+	tc.will[telnetOptComPort] = true
+	tc.do[telnetOptSGA] = true
+	if err := tc.writeRaw([]byte{
+		telnetIAC, telnetWILL, telnetOptBinary,
+		telnetIAC, telnetDO, telnetOptBinary,
+		telnetIAC, telnetDO, telnetOptSGA,
+		telnetIAC, telnetWILL, telnetOptComPort,
+	}); err != nil {
+		return err
+	}
+	baudRate := make([]byte, 4)
+	binary.BigEndian.PutUint32(baudRate, uint32(config.BaudRate))
+	for _, sb := range []struct {
+		command byte
+		value   []byte
+	}{
+		{comPortSetBaudRate, baudRate},
+		{comPortSetDataSize, []byte{byte(config.DataBits)}},
+		{comPortSetParity, []byte{comPortParityNone}},
+		{comPortSetStopSize, []byte{byte(config.StopBits)}},
+	} {
+		b := []byte{telnetIAC, telnetSB, telnetOptComPort, sb.command}
+		b = append(b, escapeIAC(sb.value)...)
+		b = append(b, telnetIAC, telnetSE)
+		if err := tc.writeRaw(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read reads the data bytes, consuming the telnet commands in between
+func (tc *telnetConn) Read(b []byte) (int, error) {
+	buf := make([]byte, len(b))
+	for {
+		n, err := tc.Conn.Read(buf)
+		out := 0
+		for _, c := range buf[:n] {
+			switch tc.state {
+			case telnetStateData:
+				if c == telnetIAC {
+					tc.state = telnetStateIAC
+					continue
+				}
+				b[out] = c
+				out++
+			case telnetStateIAC:
+				switch c {
+				case telnetIAC: // escaped 0xff
+					b[out] = c
+					out++
+					tc.state = telnetStateData
+				case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+					tc.command = c
+					tc.state = telnetStateOption
+				case telnetSB:
+					tc.state = telnetStateSB
+				default: // other commands carry no data
+					tc.state = telnetStateData
+				}
+			case telnetStateOption:
+				tc.negotiate(tc.command, c)
+				tc.state = telnetStateData
+			case telnetStateSB: // the notifications from the server are ignored
+				if c == telnetIAC {
+					tc.state = telnetStateSBIAC
+				}
+			case telnetStateSBIAC:
+				if c == telnetSE {
+					tc.state = telnetStateData
+				} else {
+					tc.state = telnetStateSB
+				}
+			}
+		}
+		if out > 0 || err != nil {
+			return out, err
+		}
+	}
+}
+
+// negotiate answers the option negotiation from the server
+// following the rule to reply only when the state changes
+func (tc *telnetConn) negotiate(command, option byte) {
+	supported := option == telnetOptBinary || option == telnetOptSGA || option == telnetOptComPort
+	switch command {
+	case telnetDO:
+		if tc.will[option] {
+			return
+		}
+		if supported {
+			tc.will[option] = true
+			tc.writeRaw([]byte{telnetIAC, telnetWILL, option})
+			return
+		}
+		tc.writeRaw([]byte{telnetIAC, telnetWONT, option})
+	case telnetDONT:
+		if tc.will[option] {
+			tc.will[option] = false
+			tc.writeRaw([]byte{telnetIAC, telnetWONT, option})
+		}
+	case telnetWILL:
+		if tc.do[option] {
+			return
+		}
+		if supported {
+			tc.do[option] = true
+			tc.writeRaw([]byte{telnetIAC, telnetDO, option})
+			return
+		}
+		tc.writeRaw([]byte{telnetIAC, telnetDONT, option})
+	case telnetWONT:
+		if tc.do[option] {
+			tc.do[option] = false
+			tc.writeRaw([]byte{telnetIAC, telnetDONT, option})
+		}
+	}
+}
+
+// Write escapes and writes the data bytes
+func (tc *telnetConn) Write(b []byte) (int, error) {
+	if err := tc.writeRaw(escapeIAC(b)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// writeRaw writes the bytes as they are
+func (tc *telnetConn) writeRaw(b []byte) error {
+	tc.writeMutex.Lock()
+	defer tc.writeMutex.Unlock()
+	_, err := tc.Conn.Write(b)
+	return err
+}
+
+// escapeIAC doubles every 0xff in b
+func escapeIAC(b []byte) []byte {
+	return bytes.Replace(b, []byte{telnetIAC}, []byte{telnetIAC, telnetIAC}, -1)
+}
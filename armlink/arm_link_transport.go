@@ -0,0 +1,37 @@
+package armlink
+
+import (
+	"io"
+)
+
+// Transport is the link carrying ArmLink frames to and from the arm
+type Transport interface {
+	// Send writes the bytes to the arm
+	Send(b []byte) error
+	// Receive reads the bytes replied by the arm into b
+	Receive(b []byte) (int, error)
+	// Close releases the link
+	Close() error
+}
+
+// Reconnector is implemented by the Transports reopening the link by themselves
+type Reconnector interface {
+	// Connected reports if the link is up
+	Connected() bool
+	// Reconnected returns the channel notified each time the link is back
+	Reconnected() <-chan struct{}
+}
+
+// transportReader reads from a Transport as an io.Reader
+type transportReader struct {
+	transport Transport
+}
+
+func (tr *transportReader) Read(b []byte) (int, error) {
+	return tr.transport.Receive(b)
+}
+
+// NewTransportReader creates an io.Reader receiving from the Transport
+func NewTransportReader(t Transport) io.Reader {
+	return &transportReader{transport: t}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+	_ "github.com/Interactions-HSG/leubot/dynamixel" // dynamixel:// device
+	"github.com/Interactions-HSG/leubot/internal/serialflags"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// Environmental variables
+var (
+	// Current Version
+	version = "0.1.0"
+
+	// app
+	app = kingpin.
+		New("armlink-replay", "Replay an ArmLink capture recorded by leubot --capture to the PhantomX AX-12 Reactor Robot Arm.")
+
+	// args
+	captureFile = app.
+			Arg("capture", "The capture file to replay.").
+			Required().
+			String()
+
+	// flags
+	speed = app.
+		Flag("speed", "The playback speed, e.g. 2 to replay twice as fast or 0 to send as fast as possible.").
+		Default("1").
+		Float64()
+
+	dryrun = app.
+		Flag("dryrun", "Print the frames without sending them.").
+		Default("false").
+		Bool()
+
+	serial = serialflags.Register(app)
+)
+
+func main() {
+	app.Version(version)
+	parse := kingpin.MustParse(app.Parse(os.Args[1:]))
+	_ = parse
+
+	if *speed < 0 {
+		log.Fatalf("invalid speed %v", *speed)
+	}
+
+	f, err := os.Open(*captureFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	frames, err := armlink.ReadCapture(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Replaying %v frames from %v at %vx", len(frames), *captureFile, *speed)
+
+	var transport armlink.Transport
+	if !*dryrun {
+		transport, err = serial.Open()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer transport.Close()
+	}
+
+	start := time.Now()
+	for _, frame := range frames {
+		// keep the original timing scaled by the speed
+		if *speed != 0 {
+			at := time.Duration(float64(frame.Offset) / *speed)
+			time.Sleep(at - time.Since(start))
+		}
+		if alp, err := armlink.ParseArmLinkPacket(frame.Bytes); err == nil {
+			log.Printf("[%v] %v", frame.Offset, alp.String())
+		} else {
+			log.Printf("[%v] % x (%v)", frame.Offset, frame.Bytes, err)
+		}
+		if *dryrun {
+			continue
+		}
+		if err := transport.Send(frame.Bytes); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Iori Mizutani
+//
+// Use of this source code is governed by The MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// the markers of the generated block in the components/schemas of the spec
+const (
+	beginMarker = "    # BEGIN armlink limits, generated by go generate ./armlink; DO NOT EDIT"
+	endMarker   = "    # END armlink limits"
+)
+
+// Environmental variables
+var (
+	// Current Version
+	version = "0.1.0"
+
+	// app
+	app = kingpin.
+		New("openapi-limits", "Write the armlink limits to the schemas of the OpenAPI spec.")
+
+	// flags
+	spec = app.
+		Flag("spec", "The OpenAPI spec to update.").
+		Default("openapi.yaml").
+		String()
+)
+
+// commands are the request bodies of the joint endpoints and the schemas of their value
+var commands = []struct {
+	name  string
+	value string
+}{
+	{"BaseCommand", "BaseValue"},
+	{"ShoulderCommand", "ShoulderValue"},
+	{"ElbowCommand", "ElbowValue"},
+	{"WristAngleCommand", "WristAngleValue"},
+	{"WristRotationCommand", "WristRotationValue"},
+	{"GripperCommand", "GripperValue"},
+}
+
+// values are the parameters in each mode
+var values = []struct {
+	name  string
+	limit armlink.Limit
+}{
+	{"BaseValue", armlink.BaseLimit},
+	{"ShoulderValue", armlink.ShoulderLimit},
+	{"ElbowValue", armlink.ElbowLimit},
+	{"WristAngleValue", armlink.WristAngleLimit},
+	{"WristRotationValue", armlink.WristRotationLimit},
+	{"GripperValue", armlink.GripperLimit},
+	{"SpeedValue", armlink.SpeedLimit},
+	{"XValue", armlink.XLimit},
+	{"YValue", armlink.YLimit},
+	{"ZValue", armlink.ZLimit},
+	{"IKBaseValue", armlink.BaseLimit},
+	{"IKWristAngleValue", armlink.IKWristAngleLimit},
+	{"IKWristRotationValue", armlink.WristRotationLimit},
+	{"IKGripperValue", armlink.GripperLimit},
+}
+
+// writeInteger writes the integer schema of the limit indented by indent
+func writeInteger(b *bytes.Buffer, indent string, l armlink.Limit) {
+	fmt.Fprintf(b, "%vtype: integer\n", indent)
+	fmt.Fprintf(b, "%vminimum: %d\n", indent, l.Min)
+	fmt.Fprintf(b, "%vmaximum: %d\n", indent, l.Max)
+	fmt.Fprintf(b, "%vdefault: %d\n", indent, l.Default)
+}
+
+// generate renders the schemas between the markers
+func generate() []byte {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, beginMarker)
+	for _, c := range commands {
+		fmt.Fprintf(&b, "    %v:\n", c.name)
+		fmt.Fprintln(&b, "      required:")
+		fmt.Fprintln(&b, "      - token")
+		fmt.Fprintln(&b, "      - value")
+		fmt.Fprintln(&b, "      type: object")
+		fmt.Fprintln(&b, "      properties:")
+		fmt.Fprintln(&b, "        token:")
+		fmt.Fprintln(&b, "          type: string")
+		fmt.Fprintln(&b, "        value:")
+		fmt.Fprintf(&b, "          $ref: '#/components/schemas/%v'\n", c.value)
+		fmt.Fprintln(&b, "        unit:")
+		fmt.Fprintln(&b, "          $ref: '#/components/schemas/Unit'")
+		fmt.Fprintln(&b, "        speed:")
+		fmt.Fprintln(&b, "          $ref: '#/components/schemas/SpeedValue'")
+		fmt.Fprintln(&b, "        queue:")
+		fmt.Fprintln(&b, "          $ref: '#/components/schemas/Queue'")
+	}
+	for _, v := range values {
+		fmt.Fprintf(&b, "    %v:\n", v.name)
+		writeInteger(&b, "      ", v.limit)
+	}
+	fmt.Fprintln(&b, endMarker)
+	return b.Bytes()
+}
+
+func main() {
+	app.Version(version)
+	parse := kingpin.MustParse(app.Parse(os.Args[1:]))
+	_ = parse
+
+	b, err := ioutil.ReadFile(*spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := string(b)
+	begin := strings.Index(s, beginMarker)
+	end := strings.Index(s, endMarker)
+	if begin < 0 || end < begin {
+		log.Fatalf("%v: no generated block, add the lines %q and %q to the schemas", *spec, beginMarker, endMarker)
+	}
+	end += len(endMarker) + 1 // and the newline
+	out := s[:begin] + string(generate()) + s[end:]
+	if err := ioutil.WriteFile(*spec, []byte(out), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -6,9 +6,15 @@
 package main
 
 import (
+	"fmt"
+	"log"
 	"os"
+	"strconv"
 
 	"github.com/Interactions-HSG/leubot/armlink"
+	_ "github.com/Interactions-HSG/leubot/dynamixel" // dynamixel:// device
+	"github.com/Interactions-HSG/leubot/internal/serialflags"
+	"github.com/Interactions-HSG/leubot/poses"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -27,87 +33,173 @@ var (
 		Default("false").
 		Bool()
 
-	baseRotation = app.
-			Flag("base", "Base rotation [0-1023].").
-			Default("512").
-			Uint16()
+	stop = app.
+		Flag("stop", "Stop the arm immediately.").
+		Default("false").
+		Bool()
 
-	shoulderRotation = app.
-				Flag("shoulder", "Shoulder rotation [205-810].").
-				Default("450").
-				Uint16()
+	mode = app.
+		Flag("mode", "Change the mode and go to home [backhoe, cartesian, cartesian90, cylindrical, cylindrical90].").
+		String()
 
-	elbowRotation = app.
-			Flag("elbow", "Elbow rotation [210-900].").
-			Default("400").
-			Uint16()
+	pose = app.
+		Flag("pose", "Go to the named pose from --poses, such as home or rest.").
+		String()
 
-	wristAngle = app.
-			Flag("wristAngle", "Wrist angle [200-830].").
-			Default("580").
-			Uint16()
+	posesfile = app.
+			Flag("poses", "The JSON file of the named poses saved by leubot.").
+			Default("poses.json").
+			String()
 
-	wristRotation = app.
-			Flag("wristRotation", "Wrist rotation [0-1023].").
-			Default("512").
-			Uint16()
+	limitsfile = app.
+			Flag("limits", "The JSON file of the ranges of the joints on this arm, as given to leubot.").
+			String()
 
-	gripper = app.
-		Flag("gripper", "Gripper [0-512].").
-		Default("255").
-		Uint16()
+	// the joints default to the home pose of --limits, known only once parsed
+	baseRotation     = jointFlag("base", "Base rotation, within the range of --limits, its default if omitted.")
+	shoulderRotation = jointFlag("shoulder", "Shoulder rotation, within the range of --limits, its default if omitted.")
+	elbowRotation    = jointFlag("elbow", "Elbow rotation, within the range of --limits, its default if omitted.")
+	wristAngle       = jointFlag("wristAngle", "Wrist angle, within the range of --limits, its default if omitted.")
+	wristRotation    = jointFlag("wristRotation", "Wrist rotation, within the range of --limits, its default if omitted.")
+	gripper          = jointFlag("gripper", "Gripper, within the range of --limits, its default if omitted.")
 
 	delta = app.
-		Flag("delta", "Delta [0-254].").
-		Default("128").
+		Flag("delta", fmt.Sprintf("Delta %v.", armlink.DeltaLimit)).
+		Default(fmt.Sprint(armlink.DeltaLimit.Default)).
 		Uint16()
 
 	button = app.
-		Flag("button", "Button [0-127].").
-		Default("0").
+		Flag("button", fmt.Sprintf("Button %v.", armlink.ButtonLimit)).
+		Default(fmt.Sprint(armlink.ButtonLimit.Default)).
 		Uint16()
 
 	extended = app.
-			Flag("extended", "Extended [0-254].").
-			Default("0").
+			Flag("extended", fmt.Sprintf("Extended %v.", armlink.ExtendedLimit)).
+			Default(fmt.Sprint(armlink.ExtendedLimit.Default)).
 			Uint16()
+
+	serial = serialflags.Register(app)
 )
 
+// jointValue is the value of a joint flag, nil until given
+type jointValue struct {
+	value *uint16
+}
+
+func (j *jointValue) Set(s string) error {
+	v, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return err
+	}
+	u := uint16(v)
+	j.value = &u
+	return nil
+}
+
+func (j *jointValue) String() string {
+	if j.value == nil {
+		return ""
+	}
+	return fmt.Sprint(*j.value)
+}
+
+// or returns the value given, or the default of the limit
+func (j *jointValue) or(limit armlink.Limit) uint16 {
+	if j.value == nil {
+		return uint16(limit.Default)
+	}
+	return *j.value
+}
+
+// jointFlag adds the flag of a joint to the app
+func jointFlag(name, help string) *jointValue {
+	j := &jointValue{}
+	app.Flag(name, help).SetValue(j)
+	return j
+}
+
 func main() {
 	app.Version(version)
 	parse := kingpin.MustParse(app.Parse(os.Args[1:]))
 	_ = parse
 
-	als := armlink.NewArmLinkSerial()
-	defer als.Close()
+	if *limitsfile != "" {
+		limits, err := armlink.LoadLimits(*limitsfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		armlink.SetJointLimits(limits)
+	}
+
+	transport, err := serial.Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer transport.Close()
 
 	var alp *armlink.ArmLinkPacket
 
-	if *reset {
-		alp = armlink.NewArmLinkPacket(
-			0,
-			0,
-			0,
-			0,
-			0,
-			0,
-			0,
-			0,
-			byte(64), // change Mode to 3D Cylindrical / 90 ° Wrist & Go to Home
-		)
+	if *stop {
+		alp = &armlink.ArmLinkPacket{}
+		alp.SetExtended(armlink.ExtendedStop) // stop all the movements
+	} else if *reset {
+		alp = &armlink.ArmLinkPacket{}
+		alp.SetExtended(armlink.ExtendedReset) // change Mode to Backhoe/Joint & Go to Home
+	} else if *mode != "" {
+		m, err := armlink.ParseMode(*mode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		alp = &armlink.ArmLinkPacket{}
+		alp.SetExtended(m.Extended())
+	} else if *pose != "" {
+		store, err := poses.Open(*posesfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p, ok := store.Get(*pose)
+		if !ok {
+			log.Fatalf("no pose %q in %v", *pose, *posesfile)
+		}
+		// the flag is wider than the byte it is sent in
+		if err := armlink.DeltaLimit.Check(armlink.ModeBackhoe, int(*delta)); err != nil {
+			log.Fatal(err)
+		}
+		alp, err = armlink.NewJointPacket(p.JointPosition(), byte(*delta))
+		if err != nil {
+			log.Fatal(err)
+		}
 	} else {
+		// the flags are wider than the bytes they are sent in
+		for _, f := range []struct {
+			limit armlink.Limit
+			value uint16
+		}{
+			{armlink.DeltaLimit, *delta},
+			{armlink.ButtonLimit, *button},
+			{armlink.ExtendedLimit, *extended},
+		} {
+			if err := f.limit.Check(armlink.ModeBackhoe, int(f.value)); err != nil {
+				log.Fatal(err)
+			}
+		}
 		// Construct ArmLink Packet based on the flags
 		alp = armlink.NewArmLinkPacket(
-			uint16(*baseRotation),     // baseRotation
-			uint16(*shoulderRotation), // shoulderRotation
-			uint16(*elbowRotation),    // elbowRotation
-			uint16(*wristAngle),       // wristAngle
-			uint16(*wristRotation),    // wristRotation
-			uint16(*gripper),          // gripper
-			byte(*delta),              // deltaByte
-			byte(*button),             // buttonByte
-			byte(*extended),           // extendedInstructionByte
+			baseRotation.or(armlink.BaseLimit),           // baseRotation
+			shoulderRotation.or(armlink.ShoulderLimit),   // shoulderRotation
+			elbowRotation.or(armlink.ElbowLimit),         // elbowRotation
+			wristAngle.or(armlink.WristAngleLimit),       // wristAngle
+			wristRotation.or(armlink.WristRotationLimit), // wristRotation
+			gripper.or(armlink.GripperLimit),             // gripper
+			byte(*delta),                                 // deltaByte
+			byte(*button),                                // buttonByte
+			byte(*extended),                              // extendedInstructionByte
 		)
+		if err := alp.Validate(armlink.ModeBackhoe); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := transport.Send(alp.Bytes()); err != nil {
+		log.Fatal(err)
 	}
-	als.Send(alp.Bytes())
 }
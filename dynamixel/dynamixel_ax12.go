@@ -0,0 +1,95 @@
+package dynamixel
+
+// Addresses in the control table of the AX-12
+const (
+	AddressTorqueEnable       byte = 24
+	AddressGoalPosition       byte = 30
+	AddressMovingSpeed        byte = 32
+	AddressPresentPosition    byte = 36
+	AddressPresentSpeed       byte = 38
+	AddressPresentLoad        byte = 40
+	AddressPresentVoltage     byte = 42
+	AddressPresentTemperature byte = 43
+)
+
+// presentSize is the length of the present values from AddressPresentPosition to AddressPresentTemperature
+const presentSize = 8
+
+// The units of the AX-12
+const (
+	// degreesPerPosition is the resolution of the position, 300° over 1024 steps
+	degreesPerPosition = 300.0 / 1024
+	// rpmPerSpeed is the resolution of the moving speed
+	rpmPerSpeed = 0.111
+	// maxSpeed is the fastest moving speed, 0 stands for no control
+	maxSpeed = 1023
+)
+
+// Joints of the Reactor arm in the order of the ArmLink frame
+const (
+	JointBase = iota
+	JointShoulder
+	JointElbow
+	JointWristAngle
+	JointWristRotation
+	JointGripper
+	jointCount
+)
+
+// jointNames are the names of the joints in the API
+var jointNames = []string{"base", "shoulder", "elbow", "wristAngle", "wristRotation", "gripper"}
+
+// Servo is an AX-12 driving a joint
+// A Reverse servo is mounted mirrored on a dual joint and gets 1023 - position
+type Servo struct {
+	ID      byte
+	Joint   int
+	Reverse bool
+}
+
+// ReactorServos is the servo layout of the PhantomX Reactor arm
+var ReactorServos = []Servo{
+	{ID: 1, Joint: JointBase},
+	{ID: 2, Joint: JointShoulder},
+	{ID: 3, Joint: JointShoulder, Reverse: true},
+	{ID: 4, Joint: JointElbow},
+	{ID: 5, Joint: JointElbow, Reverse: true},
+	{ID: 6, Joint: JointWristAngle},
+	{ID: 7, Joint: JointWristRotation},
+	{ID: 8, Joint: JointGripper},
+}
+
+// position returns the goal of the servo for the joint position
+func (s Servo) position(p uint16) uint16 {
+	if s.Reverse {
+		return 1023 - p
+	}
+	return p
+}
+
+// signed decodes the present speed and load, bit 10 is the direction
+func signed(v uint16) int {
+	if v&0x400 != 0 {
+		return -int(v & 0x3ff)
+	}
+	return int(v & 0x3ff)
+}
+
+// movingSpeed returns the moving speed covering the distance in positions in d milliseconds
+func movingSpeed(distance int, d int) uint16 {
+	if d <= 0 {
+		return 0
+	}
+	if distance < 0 {
+		distance = -distance
+	}
+	rpm := float64(distance) * degreesPerPosition / 360 / (float64(d) / 60000)
+	speed := int(rpm/rpmPerSpeed + 0.5)
+	switch {
+	case speed < 1:
+		return 1
+	case speed > maxSpeed:
+		return maxSpeed
+	}
+	return uint16(speed)
+}
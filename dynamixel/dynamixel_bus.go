@@ -0,0 +1,179 @@
+package dynamixel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+)
+
+// StatusTimeout is how long the Bus waits for a servo to reply
+var StatusTimeout = 100 * time.Millisecond
+
+// ErrTimeout is returned when a servo does not reply within StatusTimeout
+var ErrTimeout = errors.New("dynamixel: the servo did not reply")
+
+// ErrClosed is returned once the Bus is closed
+var ErrClosed = errors.New("dynamixel: bus closed")
+
+// SyncWriteData is the data written to one servo by SyncWrite
+type SyncWriteData struct {
+	ID   byte
+	Data []byte
+}
+
+// Bus exchanges the packets of the Dynamixel Protocol 1.0 with the servos over a half-duplex link
+// such as an USB2AX or an ArbotiX running a passthrough sketch, one instruction at a time
+type Bus struct {
+	port     armlink.Transport
+	mu       sync.Mutex
+	incoming chan []byte
+	buf      []byte
+}
+
+// NewBus creates a new Bus over the port and starts receiving from it
+func NewBus(port armlink.Transport) *Bus {
+	bus := &Bus{
+		port:     port,
+		incoming: make(chan []byte, 16),
+	}
+	go bus.receive()
+	return bus
+}
+
+// receive passes the bytes read from the port to the instruction waiting for them
+func (bus *Bus) receive() {
+	defer close(bus.incoming)
+	b := make([]byte, 64)
+	for {
+		n, err := bus.port.Receive(b)
+		if n > 0 {
+			bus.incoming <- append([]byte{}, b[:n]...)
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Printf("[Dynamixel] %v", err)
+		}
+	}
+}
+
+// Close closes the port
+func (bus *Bus) Close() error {
+	return bus.port.Close()
+}
+
+// exchange sends the Instruction and returns the Status replied, nil for a broadcast
+func (bus *Bus) exchange(ip *Instruction) (*Status, error) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	// discard the stale bytes, if any
+	bus.buf = nil
+	for drained := false; !drained; {
+		select {
+		case _, ok := <-bus.incoming:
+			if !ok {
+				return nil, ErrClosed
+			}
+		default:
+			drained = true
+		}
+	}
+	if err := bus.port.Send(ip.Bytes()); err != nil {
+		return nil, err
+	}
+	if ip.ID == BroadcastID {
+		return nil, nil
+	}
+	timeout := time.After(StatusTimeout)
+	for {
+		for {
+			st, n, err := parseStatus(bus.buf)
+			bus.buf = bus.buf[n:]
+			if err != nil {
+				return nil, err
+			}
+			if st != nil {
+				if st.ID != ip.ID {
+					// a late reply from another servo
+					continue
+				}
+				if st.Error != 0 {
+					return st, &StatusError{ID: st.ID, Bits: st.Error}
+				}
+				return st, nil
+			}
+			if n == 0 {
+				break
+			}
+		}
+		select {
+		case b, ok := <-bus.incoming:
+			if !ok {
+				return nil, ErrClosed
+			}
+			bus.buf = append(bus.buf, b...)
+		case <-timeout:
+			return nil, ErrTimeout
+		}
+	}
+}
+
+// Ping checks the servo is on the Bus
+func (bus *Bus) Ping(id byte) error {
+	_, err := bus.exchange(&Instruction{ID: id, Instruction: InstructionPing})
+	return err
+}
+
+// Read reads length bytes from the control table of the servo starting at the address
+func (bus *Bus) Read(id, address, length byte) ([]byte, error) {
+	st, err := bus.exchange(&Instruction{
+		ID:          id,
+		Instruction: InstructionRead,
+		Params:      []byte{address, length},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(st.Params) != int(length) {
+		return nil, fmt.Errorf("dynamixel: servo %v replied %v bytes, expected %v", id, len(st.Params), length)
+	}
+	return st.Params, nil
+}
+
+// Write writes the data to the control table of the servo starting at the address
+func (bus *Bus) Write(id, address byte, data []byte) error {
+	_, err := bus.exchange(&Instruction{
+		ID:          id,
+		Instruction: InstructionWrite,
+		Params:      append([]byte{address}, data...),
+	})
+	return err
+}
+
+// SyncWrite writes the data of the same length to several servos at once starting at the address
+func (bus *Bus) SyncWrite(address byte, data []SyncWriteData) error {
+	if len(data) == 0 {
+		return nil
+	}
+	length := len(data[0].Data)
+	params := []byte{address, byte(length)}
+	for _, d := range data {
+		if len(d.Data) != length {
+			return fmt.Errorf("dynamixel: sync write of %v bytes to servo %v, expected %v", len(d.Data), d.ID, length)
+		}
+		params = append(params, d.ID)
+		params = append(params, d.Data...)
+	}
+	_, err := bus.exchange(&Instruction{
+		ID:          BroadcastID,
+		Instruction: InstructionSyncWrite,
+		Params:      params,
+	})
+	return err
+}
@@ -0,0 +1,120 @@
+package dynamixel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Instructions of the Dynamixel Protocol 1.0
+const (
+	InstructionPing      byte = 0x01
+	InstructionRead      byte = 0x02
+	InstructionWrite     byte = 0x03
+	InstructionSyncWrite byte = 0x83
+)
+
+// BroadcastID addresses all the servos at once, none of them replies
+const BroadcastID byte = 0xfe
+
+// packetHeader starts every packet
+var packetHeader = []byte{0xff, 0xff}
+
+// statusMinSize is the length of a status packet without parameters
+const statusMinSize = 6
+
+// Instruction is a packet sent to the servos
+type Instruction struct {
+	ID          byte
+	Instruction byte
+	Params      []byte
+}
+
+// Bytes encodes the Instruction into a packet
+func (ip *Instruction) Bytes() []byte {
+	b := append([]byte{}, packetHeader...)
+	b = append(b, ip.ID, byte(len(ip.Params)+2), ip.Instruction)
+	b = append(b, ip.Params...)
+	return append(b, checksum(b[2:]))
+}
+
+// Status is a packet replied by a servo
+type Status struct {
+	ID     byte
+	Error  byte
+	Params []byte
+}
+
+// checksum computes the checksum of the bytes following the header
+func checksum(payload []byte) byte {
+	var sum byte
+	for _, v := range payload {
+		sum += v
+	}
+	return ^sum
+}
+
+// ChecksumError is returned when a status packet is corrupted
+type ChecksumError struct {
+	Checksum byte
+	Expected byte
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("dynamixel: checksum %#02x, expected %#02x", e.Checksum, e.Expected)
+}
+
+// the bits of the error byte of a Status
+var statusErrors = []string{
+	"input voltage",
+	"angle limit",
+	"overheating",
+	"range",
+	"checksum",
+	"overload",
+	"instruction",
+}
+
+// StatusError is returned when a servo reports an error in its Status
+type StatusError struct {
+	ID   byte
+	Bits byte
+}
+
+func (e *StatusError) Error() string {
+	errs := []string{}
+	for i, s := range statusErrors {
+		if e.Bits&(1<<uint(i)) != 0 {
+			errs = append(errs, s)
+		}
+	}
+	return fmt.Sprintf("dynamixel: servo %v reported %v error", e.ID, strings.Join(errs, ", "))
+}
+
+// parseStatus decodes the first Status in b and returns the number of bytes consumed,
+// 0 if more bytes are needed. The garbage before a header is skipped.
+func parseStatus(b []byte) (*Status, int, error) {
+	skipped := 0
+	for len(b) >= 2 && (b[0] != 0xff || b[1] != 0xff) {
+		b = b[1:]
+		skipped++
+	}
+	if len(b) < statusMinSize {
+		return nil, skipped, nil
+	}
+	// a third 0xff is the header shifted by one
+	if b[2] == 0xff {
+		return nil, skipped + 1, nil
+	}
+	size := int(b[3]) + 4
+	if len(b) < size {
+		return nil, skipped, nil
+	}
+	if cs := checksum(b[2 : size-1]); b[size-1] != cs {
+		return nil, skipped + size, &ChecksumError{Checksum: b[size-1], Expected: cs}
+	}
+	return &Status{
+		ID:     b[2],
+		Error:  b[4],
+		Params: append([]byte{}, b[5:size-1]...),
+	}, skipped + size, nil
+}
@@ -0,0 +1,241 @@
+package dynamixel
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+)
+
+// DefaultBaudRate is the factory baud rate of the AX-12
+const DefaultBaudRate = 1000000
+
+// errorUnsupportedMode is replied in the ArmLinkResponse to the IK modes
+const errorUnsupportedMode = byte(1)
+
+func init() {
+	armlink.RegisterDevice("dynamixel", open)
+}
+
+// open opens the Arm for the device URL dynamixel://[path][?baud=1000000]
+func open(u *url.URL, config armlink.SerialConfig) (armlink.Transport, error) {
+	if u.Path != "" {
+		config.PortName = u.Path
+	}
+	config.BaudRate = DefaultBaudRate
+	if baud := u.Query().Get("baud"); baud != "" {
+		b, err := strconv.ParseUint(baud, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid baud rate %q: %v", baud, err)
+		}
+		config.BaudRate = uint(b)
+	}
+	port, err := armlink.NewArmLinkSerial(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewArm(port, ReactorServos), nil
+}
+
+// Arm is an armlink.Transport driving the servos directly with the Dynamixel Protocol 1.0
+// It translates the ArmLink frames of the Backhoe/Joint mode into goal positions and speeds,
+// replies to the ArmID and mode instructions like the firmware, and reads the servo telemetry.
+// The IK modes are not supported and answered with an error.
+type Arm struct {
+	bus     *Bus
+	servos  []Servo
+	mu      sync.Mutex
+	goals   [jointCount]uint16
+	replies chan []byte
+	pending []byte
+	closed  chan struct{}
+}
+
+// NewArm creates a new Arm with the servos on the port
+func NewArm(port armlink.Transport, servos []Servo) *Arm {
+	return &Arm{
+		bus:     NewBus(port),
+		servos:  servos,
+		goals:   goalsOf(armlink.SleepPosition),
+		replies: make(chan []byte, 16),
+		closed:  make(chan struct{}),
+	}
+}
+
+// goalsOf returns the JointPosition indexed by the joints
+func goalsOf(jp armlink.JointPosition) [jointCount]uint16 {
+	return [jointCount]uint16{jp.Base, jp.Shoulder, jp.Elbow, jp.WristAngle, jp.WristRotation, jp.Gripper}
+}
+
+// Close closes the port and unblocks Receive
+func (arm *Arm) Close() error {
+	arm.mu.Lock()
+	select {
+	case <-arm.closed:
+	default:
+		close(arm.closed)
+	}
+	arm.mu.Unlock()
+	return arm.bus.Close()
+}
+
+// Connected reports if the port is up
+func (arm *Arm) Connected() bool {
+	if r, ok := arm.bus.port.(armlink.Reconnector); ok {
+		return r.Connected()
+	}
+	return true
+}
+
+// Reconnected returns the channel notified each time the port is back
+func (arm *Arm) Reconnected() <-chan struct{} {
+	if r, ok := arm.bus.port.(armlink.Reconnector); ok {
+		return r.Reconnected()
+	}
+	return nil
+}
+
+// Receive reads the ArmLinkResponses replied on behalf of the firmware
+func (arm *Arm) Receive(b []byte) (int, error) {
+	if len(arm.pending) == 0 {
+		select {
+		case arm.pending = <-arm.replies:
+		case <-arm.closed:
+			return 0, io.EOF
+		}
+	}
+	n := copy(b, arm.pending)
+	arm.pending = arm.pending[n:]
+	return n, nil
+}
+
+// reply queues an ArmLinkResponse
+func (arm *Arm) reply(e byte) {
+	alr := &armlink.ArmLinkResponse{
+		ArmID: armlink.ArmIDReactor,
+		Mode:  armlink.ModeBackhoe,
+		Error: e,
+	}
+	select {
+	case arm.replies <- alr.Bytes():
+	default:
+		// nobody is receiving, drop the reply
+	}
+}
+
+// Send translates the ArmLink frames in b into instructions to the servos
+func (arm *Arm) Send(b []byte) error {
+	if len(b)%armlink.ArmLinkPacketSize != 0 {
+		return fmt.Errorf("dynamixel: %v bytes is not a sequence of %v-byte frames", len(b), armlink.ArmLinkPacketSize)
+	}
+	arm.mu.Lock()
+	defer arm.mu.Unlock()
+	for i := 0; i < len(b); i += armlink.ArmLinkPacketSize {
+		alp, err := armlink.ParseArmLinkPacket(b[i : i+armlink.ArmLinkPacketSize])
+		if err != nil {
+			return fmt.Errorf("dynamixel: %v", err)
+		}
+		if err := arm.apply(alp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply performs the instruction in an ArmLinkPacket
+func (arm *Arm) apply(alp *armlink.ArmLinkPacket) error {
+	switch alp.Extended() {
+	case 0:
+		if err := alp.Validate(armlink.ModeBackhoe); err != nil {
+			return err
+		}
+		return arm.move(alp.JointPosition(), alp.Delta())
+	case armlink.ExtendedStop:
+		return arm.stop()
+	case armlink.ExtendedSleep:
+		return arm.move(armlink.SleepPosition, byte(armlink.DeltaLimit.Default))
+	case armlink.ExtendedCenter:
+		return arm.move(armlink.JointPosition{
+			Base:          512,
+			Shoulder:      512,
+			Elbow:         512,
+			WristAngle:    512,
+			WristRotation: 512,
+			Gripper:       512,
+		}, byte(armlink.DeltaLimit.Default))
+	case armlink.ExtendedArmID:
+		arm.reply(0)
+		return nil
+	case armlink.ExtendedReset:
+		err := arm.move(armlink.HomePosition(), byte(armlink.DeltaLimit.Default))
+		if err == nil {
+			arm.reply(0)
+		}
+		return err
+	}
+	if _, ok := armlink.ModeForExtended(alp.Extended()); ok {
+		arm.reply(errorUnsupportedMode)
+		return nil
+	}
+	return fmt.Errorf("dynamixel: unsupported extended instruction %v", alp.Extended())
+}
+
+// move sends every servo to the position of its joint, all arriving after delta * 16 ms
+func (arm *Arm) move(jp armlink.JointPosition, delta byte) error {
+	goals := goalsOf(jp)
+	data := []SyncWriteData{}
+	for _, s := range arm.servos {
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint16(b[0:], s.position(goals[s.Joint]))
+		binary.LittleEndian.PutUint16(b[2:], movingSpeed(int(goals[s.Joint])-int(arm.goals[s.Joint]), int(delta)*16))
+		data = append(data, SyncWriteData{ID: s.ID, Data: b})
+	}
+	if err := arm.bus.SyncWrite(AddressGoalPosition, data); err != nil {
+		return err
+	}
+	arm.goals = goals
+	return nil
+}
+
+// stop holds every servo at its present position
+func (arm *Arm) stop() error {
+	data := []SyncWriteData{}
+	for _, s := range arm.servos {
+		b, err := arm.bus.Read(s.ID, AddressPresentPosition, 2)
+		if err != nil {
+			return err
+		}
+		data = append(data, SyncWriteData{ID: s.ID, Data: b})
+		arm.goals[s.Joint] = s.position(binary.LittleEndian.Uint16(b))
+	}
+	return arm.bus.SyncWrite(AddressGoalPosition, data)
+}
+
+// Telemetry reads the present position, speed, load, voltage and temperature of each servo
+func (arm *Arm) Telemetry() ([]armlink.ServoState, error) {
+	arm.mu.Lock()
+	defer arm.mu.Unlock()
+	states := []armlink.ServoState{}
+	for _, s := range arm.servos {
+		b, err := arm.bus.Read(s.ID, AddressPresentPosition, presentSize)
+		if err != nil {
+			log.Printf("[Dynamixel] Servo %v: %v", s.ID, err)
+			return nil, err
+		}
+		states = append(states, armlink.ServoState{
+			ID:          s.ID,
+			Joint:       jointNames[s.Joint],
+			Position:    binary.LittleEndian.Uint16(b),
+			Speed:       signed(binary.LittleEndian.Uint16(b[AddressPresentSpeed-AddressPresentPosition:])),
+			Load:        signed(binary.LittleEndian.Uint16(b[AddressPresentLoad-AddressPresentPosition:])),
+			Voltage:     float64(b[AddressPresentVoltage-AddressPresentPosition]) / 10,
+			Temperature: int(b[AddressPresentTemperature-AddressPresentPosition]),
+		})
+	}
+	return states, nil
+}
@@ -0,0 +1,112 @@
+// Package serialflags defines the flags of the device of the arm and of its serial port,
+// shared by leubot, reactor-ctrl and armlink-replay.
+package serialflags
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// Flags are the values of the flags once parsed
+type Flags struct {
+	Device                *string
+	SerialPort            *string
+	BaudRate              *uint
+	DataBits              *uint
+	StopBits              *uint
+	InterCharacterTimeout *uint
+	MinimumReadSize       *uint
+	ReadTimeout           *uint
+	Autodetect            *bool
+	USBID                 *string
+}
+
+// Register adds the flags to the app
+func Register(app *kingpin.Application) *Flags {
+	return &Flags{
+		Device: app.
+			Flag("device", "The device URL of the arm: serial://[path], tcp://host:port, rfc2217://host:port, dynamixel://[path] for the servos directly or sim:// for the virtual arm.").
+			Default("serial://").
+			String(),
+		SerialPort: app.
+			Flag("serialport", "The serial port connected to the arm.").
+			Default(armlink.DefaultSerialConfig.PortName).
+			String(),
+		BaudRate: app.
+			Flag("baudrate", "The baud rate of the serial port.").
+			Default(fmt.Sprint(armlink.DefaultSerialConfig.BaudRate)).
+			Uint(),
+		DataBits: app.
+			Flag("databits", "The number of data bits of the serial port.").
+			Default(fmt.Sprint(armlink.DefaultSerialConfig.DataBits)).
+			Uint(),
+		StopBits: app.
+			Flag("stopbits", "The number of stop bits of the serial port.").
+			Default(fmt.Sprint(armlink.DefaultSerialConfig.StopBits)).
+			Uint(),
+		InterCharacterTimeout: app.
+			Flag("intercharactertimeout", "The inter-character timeout of the serial port in milliseconds, a multiple of 100.").
+			Default(fmt.Sprint(armlink.DefaultSerialConfig.InterCharacterTimeout)).
+			Uint(),
+		MinimumReadSize: app.
+			Flag("minimumreadsize", "The minimum number of bytes to read from the serial port at once.").
+			Default(fmt.Sprint(armlink.DefaultSerialConfig.MinimumReadSize)).
+			Uint(),
+		ReadTimeout: app.
+			Flag("readtimeout", "The longest wait of a read of the serial port for a byte in milliseconds, a multiple of 100, over --intercharactertimeout and --minimumreadsize; 0 to wait for them.").
+			Default(fmt.Sprint(armlink.DefaultSerialConfig.ReadTimeout)).
+			Uint(),
+		Autodetect: app.
+			Flag("autodetect", "Find the serial port by the USB ID instead of --serialport.").
+			Default("false").
+			Bool(),
+		USBID: app.
+			Flag("usbid", "The USB vendor:product ID of the serial cable for --autodetect.").
+			Default(armlink.FTDIUSBID).
+			String(),
+	}
+}
+
+// Config returns the SerialConfig of the flags, with the serial port found by its USB ID on --autodetect,
+// or an error if --autodetect is given with a device naming its own port
+func (f *Flags) Config() (armlink.SerialConfig, error) {
+	config := armlink.SerialConfig{
+		PortName:              *f.SerialPort,
+		BaudRate:              *f.BaudRate,
+		DataBits:              *f.DataBits,
+		StopBits:              *f.StopBits,
+		InterCharacterTimeout: *f.InterCharacterTimeout,
+		MinimumReadSize:       *f.MinimumReadSize,
+		ReadTimeout:           *f.ReadTimeout,
+	}
+	if !*f.Autodetect {
+		return config, nil
+	}
+	u, err := url.Parse(*f.Device)
+	if err != nil {
+		return config, fmt.Errorf("invalid device %q: %v", *f.Device, err)
+	}
+	if (u.Scheme != "" && u.Scheme != "serial") || u.Path != "" {
+		return config, fmt.Errorf("--autodetect finds the serial port of serial://, not of --device %v", *f.Device)
+	}
+	port, err := armlink.FindSerialPort(*f.USBID)
+	if err != nil {
+		return config, err
+	}
+	log.Printf("[Transport] Found %v for %v", port, *f.USBID)
+	config.PortName = port
+	return config, nil
+}
+
+// Open opens the Transport of the device with the Config
+func (f *Flags) Open() (armlink.Transport, error) {
+	config, err := f.Config()
+	if err != nil {
+		return nil, err
+	}
+	return armlink.Open(*f.Device, config)
+}
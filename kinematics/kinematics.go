@@ -0,0 +1,105 @@
+// Package kinematics computes the position of the gripper of the Reactor from its joints and back,
+// so that leubot does not depend on the IK modes of the ArmLink firmware.
+package kinematics
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrUnreachable is returned when the position is out of the reach of the arm
+var ErrUnreachable = errors.New("kinematics: the position is out of reach")
+
+// Geometry is the lengths of the links of the arm in millimeters
+type Geometry struct {
+	BaseHeight float64 // from the table to the shoulder
+	Humerus    float64 // from the shoulder to the elbow
+	Ulna       float64 // from the elbow to the wrist
+	Gripper    float64 // from the wrist to the tip of the gripper
+}
+
+// ReactorGeometry is the approximate Geometry of the PhantomX Reactor
+var ReactorGeometry = Geometry{
+	BaseHeight: 90,
+	Humerus:    150,
+	Ulna:       150,
+	Gripper:    140,
+}
+
+// Angles are the angles of the joints in radians, all 0 with the arm straight up
+// The Base turns towards +X and the others lean the arm forward
+type Angles struct {
+	Base     float64
+	Shoulder float64
+	Elbow    float64
+	Wrist    float64
+}
+
+// Position is the tip of the gripper in millimeters, Y forward from the base and Z up from the table,
+// with the Pitch of the gripper in radians, 0 horizontal pointing away from the base and positive up
+type Position struct {
+	X     float64
+	Y     float64
+	Z     float64
+	Pitch float64
+}
+
+// Forward returns the Position of the gripper for the Angles
+func (g Geometry) Forward(a Angles) Position {
+	humerus := a.Shoulder
+	ulna := humerus + a.Elbow
+	gripper := ulna + a.Wrist
+	r := g.Humerus*math.Sin(humerus) + g.Ulna*math.Sin(ulna) + g.Gripper*math.Sin(gripper)
+	// the gripper points away from the base when the arm leans forward, towards it over the back
+	away := math.Sin(gripper)
+	if r < 0 {
+		away = -away
+	}
+	return Position{
+		X:     r * math.Sin(a.Base),
+		Y:     r * math.Cos(a.Base),
+		Z:     g.BaseHeight + g.Humerus*math.Cos(humerus) + g.Ulna*math.Cos(ulna) + g.Gripper*math.Cos(gripper),
+		Pitch: math.Atan2(math.Cos(gripper), away),
+	}
+}
+
+// Inverse returns the Angles reaching the Position facing it or over the back, with the elbow on either side,
+// or ErrUnreachable if the wrist can not get there
+func (g Geometry) Inverse(p Position) ([]Angles, error) {
+	solutions := []Angles{}
+	front := math.Atan2(p.X, p.Y)
+	for _, side := range []struct {
+		base float64
+		r    float64
+		// the pitch in the plane of the arm, from the direction the base faces
+		pitch float64
+	}{
+		{front, math.Hypot(p.X, p.Y), p.Pitch},
+		{math.Remainder(front+math.Pi, 2*math.Pi), -math.Hypot(p.X, p.Y), math.Pi - p.Pitch},
+	} {
+		// the wrist in the plane of the arm, from the shoulder
+		r := side.r - g.Gripper*math.Cos(side.pitch)
+		z := p.Z - g.BaseHeight - g.Gripper*math.Sin(side.pitch)
+		c := (r*r + z*z - g.Humerus*g.Humerus - g.Ulna*g.Ulna) / (2 * g.Humerus * g.Ulna)
+		if c < -1 || c > 1 {
+			continue
+		}
+		for _, elbow := range []float64{math.Acos(c), -math.Acos(c)} {
+			shoulder := math.Atan2(r, z) - math.Atan2(g.Ulna*math.Sin(elbow), g.Humerus+g.Ulna*math.Cos(elbow))
+			solutions = append(solutions, Angles{
+				Base:     side.base,
+				Shoulder: shoulder,
+				Elbow:    elbow,
+				Wrist:    math.Remainder(math.Pi/2-side.pitch-shoulder-elbow, 2*math.Pi),
+			})
+			if elbow == 0 {
+				// the arm is stretched, both are the same
+				break
+			}
+		}
+	}
+	if len(solutions) == 0 {
+		return nil, ErrUnreachable
+	}
+	return solutions, nil
+}
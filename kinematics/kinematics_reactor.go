@@ -0,0 +1,109 @@
+package kinematics
+
+import (
+	"fmt"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+	"github.com/Interactions-HSG/leubot/units"
+)
+
+// Reactor is the PhantomX Reactor with its Geometry and the Calibration of its servos,
+// which must put the angle 0 with the arm straight up
+type Reactor struct {
+	Geometry    Geometry
+	Calibration units.Calibration
+}
+
+// joint returns the Joint of the Calibration with the direction of the Angles,
+// the shoulder, the elbow and the wrist of the Reactor leaning the arm forward as their ticks go down
+func (arm Reactor) joint(l armlink.Limit) units.Joint {
+	return units.Joint{
+		Zero:    arm.Calibration[l.Name].Zero,
+		Reverse: l.Name != armlink.BaseLimit.Name,
+	}
+}
+
+// Angles returns the Angles of the joints in the JointPosition
+func (arm Reactor) Angles(jp armlink.JointPosition) Angles {
+	angle := func(l armlink.Limit, ticks uint16) float64 {
+		return arm.joint(l).FromTicks(int(ticks), units.Radians)
+	}
+	return Angles{
+		Base:     angle(armlink.BaseLimit, jp.Base),
+		Shoulder: angle(armlink.ShoulderLimit, jp.Shoulder),
+		Elbow:    angle(armlink.ElbowLimit, jp.Elbow),
+		Wrist:    angle(armlink.WristAngleLimit, jp.WristAngle),
+	}
+}
+
+// Forward returns the Position of the gripper in the JointPosition
+func (arm Reactor) Forward(jp armlink.JointPosition) Position {
+	return arm.Geometry.Forward(arm.Angles(jp))
+}
+
+// Inverse returns the JointPosition reaching the Position nearest to the current one,
+// keeping its wrist rotation and gripper, or an error if none is within the joint limits
+func (arm Reactor) Inverse(p Position, current armlink.JointPosition) (armlink.JointPosition, error) {
+	solutions, err := arm.Geometry.Inverse(p)
+	if err != nil {
+		return current, err
+	}
+	var best *armlink.JointPosition
+	bestDistance := 0
+	for _, a := range solutions {
+		jp, e := arm.jointPosition(a, current)
+		if e != nil {
+			// report the solution facing the position
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		if d := distance(jp, current); best == nil || d < bestDistance {
+			best, bestDistance = &jp, d
+		}
+	}
+	if best == nil {
+		return current, fmt.Errorf("kinematics: the position is out of the joint limits, %v", err)
+	}
+	return *best, nil
+}
+
+// jointPosition returns the JointPosition of the Angles with the rest of the current one,
+// or a RangeError if a joint is out of its limits
+func (arm Reactor) jointPosition(a Angles, current armlink.JointPosition) (armlink.JointPosition, error) {
+	limits := []armlink.Limit{armlink.BaseLimit, armlink.ShoulderLimit, armlink.ElbowLimit, armlink.WristAngleLimit}
+	ticks := []int{}
+	for i, angle := range []float64{a.Base, a.Shoulder, a.Elbow, a.Wrist} {
+		t := arm.joint(limits[i]).ToTicks(angle, units.Radians)
+		if err := limits[i].Check(armlink.ModeBackhoe, t); err != nil {
+			return current, err
+		}
+		ticks = append(ticks, t)
+	}
+	return armlink.JointPosition{
+		Base:          uint16(ticks[0]),
+		Shoulder:      uint16(ticks[1]),
+		Elbow:         uint16(ticks[2]),
+		WristAngle:    uint16(ticks[3]),
+		WristRotation: current.WristRotation,
+		Gripper:       current.Gripper,
+	}, nil
+}
+
+// distance returns how far the joints move between the JointPositions, in ticks
+func distance(a, b armlink.JointPosition) int {
+	d := 0
+	for _, v := range []int{
+		int(a.Base) - int(b.Base),
+		int(a.Shoulder) - int(b.Shoulder),
+		int(a.Elbow) - int(b.Elbow),
+		int(a.WristAngle) - int(b.WristAngle),
+	} {
+		if v < 0 {
+			v = -v
+		}
+		d += v
+	}
+	return d
+}
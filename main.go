@@ -10,16 +10,30 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"os/exec"
+	"sync"
 	"time"
 
 	"github.com/Interactions-HSG/leubot/api"
 	"github.com/Interactions-HSG/leubot/armlink"
+	_ "github.com/Interactions-HSG/leubot/dynamixel" // dynamixel:// device
+	"github.com/Interactions-HSG/leubot/internal/serialflags"
+	"github.com/Interactions-HSG/leubot/kinematics"
+	"github.com/Interactions-HSG/leubot/poses"
+	"github.com/Interactions-HSG/leubot/programs"
+	"github.com/Interactions-HSG/leubot/recordings"
+	"github.com/Interactions-HSG/leubot/safety"
+	"github.com/Interactions-HSG/leubot/trajectory"
+	"github.com/Interactions-HSG/leubot/units"
 	"github.com/badoux/checkmail"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -72,6 +86,74 @@ var (
 			Flag("userTimeout", "The timeout duration for users in seconds.").
 			Default("900").
 			Int()
+
+	confirmTimeout = app.
+			Flag("confirmTimeout", "The timeout for the arm to confirm a reset in milliseconds, 0 to skip the confirmation.").
+			Default("1000").
+			Int()
+
+	defaultspeed = app.
+			Flag("defaultspeed", "The speed of the moves from 1 to 100 for the users and the commands without their own.").
+			Default(fmt.Sprint(armlink.SpeedLimit.Default)).
+			Int()
+
+	maxspeed = app.
+			Flag("maxspeed", "The maximum speed of the moves from 1 to 100, the faster commands are slowed down to it.").
+			Default(fmt.Sprint(armlink.SpeedLimit.Max)).
+			Int()
+
+	limitsfile = app.
+			Flag("limits", "The JSON file of the ranges of the joints on this arm and their defaults in the home pose, within the firmware limits.").
+			String()
+
+	calibrationfile = app.
+			Flag("calibration", "The JSON file of the zero offsets and directions of the joints for the values in degrees and radians.").
+			String()
+
+	trajectoryperiod = app.
+				Flag("trajectoryperiod", "The period of the frames streamed along the trajectories in milliseconds.").
+				Default("50").
+				Int()
+
+	posesfile = app.
+			Flag("poses", "The JSON file of the named poses.").
+			Default("poses.json").
+			String()
+
+	programtimeout = app.
+			Flag("programtimeout", "The longest time a program runs in seconds, the waits included.").
+			Default("300").
+			Int()
+
+	programsteps = app.
+			Flag("programsteps", "The most Starlark computation steps a program takes.").
+			Default("1000000").
+			Uint64()
+
+	safetyfile = app.
+			Flag("safety", "The JSON file of the allowed zone and the keep-out zones of the gripper in Joint mode.").
+			String()
+
+	recordingsdir = app.
+			Flag("recordings", "The directory of the recordings of the teach mode.").
+			Default("recorded").
+			String()
+
+	clamp = app.
+		Flag("clamp", "Clamp the relative moves at the joint limits, --no-clamp to reject them instead.").
+		Default("true").
+		Bool()
+
+	simulator = app.
+			Flag("simulator", "Drive the built-in virtual Reactor arm, as --device sim://.").
+			Default("false").
+			Bool()
+
+	capture = app.
+		Flag("capture", "Record every frame sent to the arm into the capture file.").
+		String()
+
+	serial = serialflags.Register(app)
 )
 
 // RobotPose stores the rotations of each joint
@@ -84,9 +166,48 @@ type RobotPose struct {
 	Gripper       uint16
 }
 
-// BuildArmLinkPacket creates a new ArmLinkPacket
-func (rp *RobotPose) BuildArmLinkPacket() *armlink.ArmLinkPacket {
-	return armlink.NewArmLinkPacket(rp.Base, rp.Shoulder, rp.Elbow, rp.WristAngle, rp.WristRotation, rp.Gripper, 128, 0, 0)
+// BuildArmLinkPacket creates a new ArmLinkPacket moving in delta * 16 ms, or returns an error if the rp is out of the limits
+func (rp *RobotPose) BuildArmLinkPacket(delta byte) (*armlink.ArmLinkPacket, error) {
+	return armlink.NewJointPacket(armlink.JointPosition(*rp), delta)
+}
+
+// Joint returns the value of the joint by its name and its Limit
+func (rp *RobotPose) Joint(name string) (*uint16, armlink.Limit, bool) {
+	switch name {
+	case armlink.BaseLimit.Name:
+		return &rp.Base, armlink.BaseLimit, true
+	case armlink.ShoulderLimit.Name:
+		return &rp.Shoulder, armlink.ShoulderLimit, true
+	case armlink.ElbowLimit.Name:
+		return &rp.Elbow, armlink.ElbowLimit, true
+	case armlink.WristAngleLimit.Name:
+		return &rp.WristAngle, armlink.WristAngleLimit, true
+	case armlink.WristRotationLimit.Name:
+		return &rp.WristRotation, armlink.WristRotationLimit, true
+	case armlink.GripperLimit.Name:
+		return &rp.Gripper, armlink.GripperLimit, true
+	}
+	return nil, armlink.Limit{}, false
+}
+
+// poseStore has the named poses, home among them
+var poseStore *poses.Store
+
+// recordingStore has the finished recordings
+var recordingStore *recordings.Store
+
+// envelope is where the gripper may go in Joint mode, anywhere if nil
+var envelope *safety.Envelope
+
+// calibration converts the joint values in degrees and radians to the ticks of the RobotPose
+var calibration = units.DefaultCalibration
+
+// reactor computes the kinematics of the arm with the calibration
+func reactor() kinematics.Reactor {
+	return kinematics.Reactor{
+		Geometry:    kinematics.ReactorGeometry,
+		Calibration: calibration,
+	}
 }
 
 // String returns a string rep for the rp
@@ -94,77 +215,1044 @@ func (rp *RobotPose) String() string {
 	return fmt.Sprintf("Base: %v, Shoulder: %v, Elbow: %v, WristAngle: %v, WristRotation: %v, Gripper: %v", rp.Base, rp.Shoulder, rp.Elbow, rp.WristAngle, rp.WristRotation, rp.Gripper)
 }
 
+// RobotPosition stores the position of the end-effector in the IK modes
+type RobotPosition struct {
+	X             int
+	Y             int
+	Z             int
+	Base          uint16
+	WristAngle    int
+	WristRotation uint16
+	Gripper       uint16
+}
+
+// BuildArmLinkPacket creates a new ArmLinkPacket for the IK mode moving in delta * 16 ms, or returns an error if the rp is out of the limits
+func (rp *RobotPosition) BuildArmLinkPacket(mode armlink.Mode, delta byte) (*armlink.ArmLinkPacket, error) {
+	if mode.IsCylindrical() {
+		return armlink.NewCylindricalPacket(armlink.CylindricalPosition{
+			Base:          rp.Base,
+			Y:             rp.Y,
+			Z:             rp.Z,
+			WristAngle:    rp.WristAngle,
+			WristRotation: rp.WristRotation,
+			Gripper:       rp.Gripper,
+		}, delta)
+	}
+	return armlink.NewCartesianPacket(armlink.CartesianPosition{
+		X:             rp.X,
+		Y:             rp.Y,
+		Z:             rp.Z,
+		WristAngle:    rp.WristAngle,
+		WristRotation: rp.WristRotation,
+		Gripper:       rp.Gripper,
+	}, delta)
+}
+
+// String returns a string rep for the rp
+func (rp *RobotPosition) String() string {
+	return fmt.Sprintf("X: %v, Y: %v, Z: %v, Base: %v, WristAngle: %v, WristRotation: %v, Gripper: %v", rp.X, rp.Y, rp.Z, rp.Base, rp.WristAngle, rp.WristRotation, rp.Gripper)
+}
+
+// errArmNotResponding is returned when the arm does not confirm an instruction
+var errArmNotResponding = errors.New("the arm did not respond")
+
+// errStopped is returned when a move is sent after an emergency stop
+var errStopped = errors.New("the arm is stopped until re-armed")
+
 // Controller is the main thread for this API provider
 type Controller struct {
-	ArmLinkSerial     *armlink.ArmLinkSerial
-	CurrentRobotPose  *RobotPose
-	CurrentUser       *api.User
-	HandlerChannel    chan api.HandlerMessage
-	LastArmLinkPacket *armlink.ArmLinkPacket
-	UserActChannel    chan bool
-	UserTimer         *time.Timer
-	UserTimerFinish   chan bool
+	ArmLinkResponseChannel chan armlink.ArmLinkResponse
+	CurrentMode            armlink.Mode
+	CurrentRobotPose       *RobotPose
+	CurrentRobotPosition   *RobotPosition
+	CurrentUser            *api.User
+	HandlerChannel         chan api.HandlerMessage
+	LastArmLinkPacket      *armlink.ArmLinkPacket
+	LastArmLinkResponse    *armlink.ArmLinkResponse
+	Transport              armlink.Transport
+	UserTimer              *time.Timer
+	armStatusMutex         sync.Mutex
+	asleep                 bool
+	stopped                bool
+	stopMutex              sync.Mutex
+	trajectory             *trajectoryRun
+	trajectoryCount        int
+	recording              *recordings.Recording
+	recordingStarted       time.Time
+	program                *programs.Run
+	programCount           int
+	commands               map[string]*commandRun
+	commandLine            []*commandRun
+	commandCount           int
+	commandTimer           *time.Timer
+}
+
+// trajectoryRun is the latest trajectory streamed to the arm
+type trajectoryRun struct {
+	id         string
+	trajectory *trajectory.Trajectory
+	started    time.Time
+	ticker     *time.Ticker
+	status     string
+	elapsed    time.Duration
+	waypoint   int
+	frame      *armlink.ArmLinkPacket
+}
+
+// commandRun is a motion command accepted by the controller, with the pose and the position
+// it leaves the arm in and the ones before it, to go back to if it is canceled before being sent
+type commandRun struct {
+	id             string
+	owner          string
+	frame          *armlink.ArmLinkPacket
+	mode           armlink.Mode
+	pose           RobotPose
+	position       RobotPosition
+	beforePose     RobotPose
+	beforePosition RobotPosition
+	status         string
+	err            error
+	created        time.Time
+	started        time.Time
+	finished       time.Time
+	measured       map[string]int
+}
+
+// estimated returns when the arm is expected to reach the pose of the command, from the delta of its frame
+func (c *commandRun) estimated() time.Time {
+	return c.started.Add(time.Duration(c.frame.Delta()) * 16 * time.Millisecond)
+}
+
+// The bounds of the motion commands
+const (
+	// maxQueued is the most commands waiting behind the executing one
+	maxQueued = 32
+	// maxCommands is how many of the latest commands keep their status
+	maxCommands = 100
+	// commandGrace is how long a command waits past its estimate for the servos to stop, if they are read
+	commandGrace = time.Second
+)
+
+// ArmStatus returns the last ArmLinkResponse from the arm, nil if none received yet
+func (controller *Controller) ArmStatus() *armlink.ArmLinkResponse {
+	controller.armStatusMutex.Lock()
+	defer controller.armStatusMutex.Unlock()
+	if controller.LastArmLinkResponse == nil {
+		return nil
+	}
+	alr := *controller.LastArmLinkResponse
+	return &alr
+}
+
+// Stopped reports if the arm is held by an emergency stop
+func (controller *Controller) Stopped() bool {
+	controller.stopMutex.Lock()
+	defer controller.stopMutex.Unlock()
+	return controller.stopped
+}
+
+// setStopped latches or releases the emergency stop
+func (controller *Controller) setStopped(stopped bool) {
+	controller.stopMutex.Lock()
+	defer controller.stopMutex.Unlock()
+	controller.stopped = stopped
 }
 
-// ResetPose resets the RobotPose to its home position
+// ResetPose resets the RobotPose to the home pose
+// The pose is frozen while the arm is stopped
 func (controller *Controller) ResetPose() {
-	controller.CurrentRobotPose = &RobotPose{
-		Base:          512,
-		Shoulder:      400,
-		Elbow:         400,
-		WristAngle:    580,
-		WristRotation: 512,
-		Gripper:       128,
+	if controller.Stopped() {
+		return
+	}
+	pose := RobotPose(homePosition())
+	controller.CurrentRobotPose = &pose
+}
+
+// homePosition returns the home pose from the poseStore, the defaults of the Limits unless overridden
+func homePosition() armlink.JointPosition {
+	if home, ok := poseStore.Get("home"); ok {
+		return home.JointPosition()
+	}
+	return armlink.HomePosition()
+}
+
+// ResetPosition resets the RobotPosition to its home position in the IK modes
+// The position is frozen while the arm is stopped
+func (controller *Controller) ResetPosition() {
+	if controller.Stopped() {
+		return
+	}
+	controller.CurrentRobotPosition = &RobotPosition{
+		X:             armlink.XLimit.Default,
+		Y:             armlink.YLimit.Default,
+		Z:             armlink.ZLimit.Default,
+		Base:          uint16(armlink.BaseLimit.Default),
+		WristAngle:    armlink.IKWristAngleLimit.Default,
+		WristRotation: uint16(armlink.WristRotationLimit.Default),
+		Gripper:       uint16(armlink.GripperLimit.Default),
+	}
+}
+
+// buildArmLinkPacket creates a new ArmLinkPacket for the CurrentRobotPose
+// or the CurrentRobotPosition depending on the CurrentMode
+func (controller *Controller) buildArmLinkPacket() (*armlink.ArmLinkPacket, error) {
+	delta, _ := controller.delta(nil)
+	if controller.CurrentMode == armlink.ModeBackhoe {
+		return controller.CurrentRobotPose.BuildArmLinkPacket(delta)
+	}
+	return controller.CurrentRobotPosition.BuildArmLinkPacket(controller.CurrentMode, delta)
+}
+
+// delta returns the delta byte for the speed of a command, or the speed of the CurrentUser
+// or --defaultspeed if nil, slowed down to --maxspeed
+func (controller *Controller) delta(speed *int) (byte, error) {
+	s := *defaultspeed
+	if controller.CurrentUser.Speed != nil {
+		s = *controller.CurrentUser.Speed
+	}
+	if speed != nil {
+		if err := armlink.SpeedLimit.Check(controller.CurrentMode, *speed); err != nil {
+			return 0, err
+		}
+		s = *speed
+	}
+	if s > *maxspeed {
+		s = *maxspeed
+	}
+	return armlink.SpeedDelta(s), nil
+}
+
+// buildPose creates a new ArmLinkPacket for the pose moving at the speed, or returns an error if either is invalid
+// or the pose takes the gripper out of the envelope
+func (controller *Controller) buildPose(pose *RobotPose, speed *int) (*armlink.ArmLinkPacket, error) {
+	delta, err := controller.delta(speed)
+	if err != nil {
+		return nil, err
+	}
+	if envelope != nil {
+		if err := envelope.CheckPose(armlink.JointPosition(*pose), reactor()); err != nil {
+			return nil, err
+		}
+	}
+	return pose.BuildArmLinkPacket(delta)
+}
+
+// setJoint sets the joint of the pose to the value in the unit, or returns an error if it is out of the limits
+func setJoint(pose *RobotPose, joint string, v float64, unit string) error {
+	u, err := units.ParseUnit(unit)
+	if err != nil {
+		return err
+	}
+	value, limit, ok := pose.Joint(joint)
+	if !ok {
+		return fmt.Errorf("unknown joint %q", joint)
+	}
+	ticks := calibration[joint].ToTicks(v, u)
+	if err := limit.Check(armlink.ModeBackhoe, ticks); err != nil {
+		if u != units.Ticks {
+			return fmt.Errorf("%v, given %v %v", err, v, u)
+		}
+		return err
+	}
+	*value = uint16(ticks)
+	return nil
+}
+
+// setJoints sets the joints of the pose given in the unit, the nil ones are kept
+func setJoints(pose *RobotPose, unit string, base, shoulder, elbow, wristAngle, wristRotation, gripper *float64) error {
+	for _, jv := range []struct {
+		joint string
+		value *float64
+	}{
+		{armlink.BaseLimit.Name, base},
+		{armlink.ShoulderLimit.Name, shoulder},
+		{armlink.ElbowLimit.Name, elbow},
+		{armlink.WristAngleLimit.Name, wristAngle},
+		{armlink.WristRotationLimit.Name, wristRotation},
+		{armlink.GripperLimit.Name, gripper},
+	} {
+		if jv.value == nil {
+			continue
+		}
+		if err := setJoint(pose, jv.joint, *jv.value, unit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildJoint creates a new ArmLinkPacket for the CurrentRobotPose with the joint set by the robotCommand,
+// or returns an error if the value, the unit or the speed is invalid
+func (controller *Controller) buildJoint(joint string, robotCommand api.RobotCommand) (*RobotPose, *armlink.ArmLinkPacket, error) {
+	pose := *controller.CurrentRobotPose
+	if err := setJoint(&pose, joint, robotCommand.Value, robotCommand.Unit); err != nil {
+		return nil, nil, err
+	}
+	alp, err := controller.buildPose(&pose, robotCommand.Speed)
+	return &pose, alp, err
+}
+
+// buildTrajectory creates a new Trajectory from the pose through the waypoints of the trajectoryCommand,
+// or returns an error if any of them is invalid
+func buildTrajectory(pose RobotPose, trajectoryCommand api.TrajectoryCommand) (*trajectory.Trajectory, error) {
+	interpolation, err := trajectory.ParseInterpolation(trajectoryCommand.Interpolation)
+	if err != nil {
+		return nil, err
+	}
+	t := &trajectory.Trajectory{
+		Start:         armlink.JointPosition(pose),
+		Interpolation: interpolation,
+	}
+	waypoints := trajectoryCommand.Waypoints
+	if len(waypoints) == 0 {
+		waypoints = []api.TrajectoryWaypoint{trajectoryCommand.TrajectoryWaypoint}
+	}
+	for i, w := range waypoints {
+		if w.Duration <= 0 {
+			return nil, fmt.Errorf("waypoint %v: the duration must be positive", i)
+		}
+		if err := setJoints(&pose, trajectoryCommand.Unit, w.Base, w.Shoulder, w.Elbow, w.WristAngle, w.WristRotation, w.Gripper); err != nil {
+			return nil, fmt.Errorf("waypoint %v: %v", i, err)
+		}
+		t.Waypoints = append(t.Waypoints, trajectory.Waypoint{
+			Position: armlink.JointPosition(pose),
+			Duration: time.Duration(w.Duration) * time.Millisecond,
+		})
+	}
+	return t, nil
+}
+
+// checkTrajectory returns an error if a frame of the Trajectory takes the gripper out of the envelope
+func checkTrajectory(t *trajectory.Trajectory) error {
+	if envelope == nil {
+		return nil
+	}
+	return envelope.CheckTrajectory(t, time.Duration(*trajectoryperiod)*time.Millisecond, reactor())
+}
+
+// commandError returns the reply to a command rejected for the err, TypeUnsafePose if out of the envelope
+func commandError(err error) api.HandlerMessage {
+	if _, ok := err.(*safety.Violation); ok {
+		return api.HandlerMessage{
+			Type:  api.TypeUnsafePose,
+			Value: []interface{}{err.Error()},
+		}
+	}
+	return api.HandlerMessage{
+		Type:  api.TypeInvalidCommand,
+		Value: []interface{}{err.Error()},
+	}
+}
+
+// sync sends the CurrentRobotPose or the CurrentRobotPosition depending on the CurrentMode,
+// unless the pose takes the gripper out of the envelope
+func (controller *Controller) sync() error {
+	alp, err := controller.buildArmLinkPacket()
+	if err == nil && envelope != nil && controller.CurrentMode == armlink.ModeBackhoe {
+		err = envelope.CheckPose(armlink.JointPosition(*controller.CurrentRobotPose), reactor())
+	}
+	if err != nil {
+		log.Printf("[ArmLinkPacket] %v", err)
+		return err
 	}
+	if err := controller.send(alp); err != nil {
+		return err
+	}
+	log.Printf("[ArmLinkPacket] %v", alp.String())
+	return nil
 }
 
 // Shutdown processes the graceful termination of the program
 func (controller *Controller) Shutdown() {
 	// set the robot in sleep mode
-	alp := armlink.ArmLinkPacket{}
+	alp := &armlink.ArmLinkPacket{}
+	alp.SetExtended(armlink.ExtendedSleep)
+	controller.send(alp)
+	// turn off the light
+	switchLight(false)
+}
+
+// send writes the ArmLinkPacket to the Transport
+// Only the stop and the ID request go through while the arm is stopped
+func (controller *Controller) send(alp *armlink.ArmLinkPacket) error {
+	if controller.Stopped() && alp.Extended() != armlink.ExtendedStop && alp.Extended() != armlink.ExtendedArmID {
+		log.Printf("[Stop] Suppressed %v", alp.String())
+		return errStopped
+	}
+	// any other move cancels the running trajectory
+	if run := controller.trajectory; run != nil && run.ticker != nil && alp != run.frame && alp.Extended() != armlink.ExtendedArmID {
+		controller.endTrajectory(api.TrajectoryCanceled)
+	}
+	// and the motion commands in line, unless it is the one at their head
+	if line := controller.commandLine; len(line) > 0 && alp != line[0].frame && alp.Extended() != armlink.ExtendedArmID {
+		controller.cancelCommands(0, errors.New("superseded by another move"))
+	}
+	if err := controller.Transport.Send(alp.Bytes()); err != nil {
+		log.Printf("[Transport] %v", err)
+		return err
+	}
+	controller.LastArmLinkPacket = alp
+	// record the joint moves, if recording
+	if rec := controller.recording; rec != nil && alp.Extended() == 0 && controller.CurrentMode == armlink.ModeBackhoe {
+		rec.Add(time.Since(controller.recordingStarted), alp.JointPosition(), alp.Delta())
+	}
+	// keep track of the sleep mode, any move or mode change wakes the arm up
+	controller.armStatusMutex.Lock()
+	switch e := alp.Extended(); {
+	case e == armlink.ExtendedSleep:
+		controller.asleep = true
+	case e == 0:
+		controller.asleep = false
+	default:
+		if _, ok := armlink.ModeForExtended(e); ok {
+			controller.asleep = false
+		}
+	}
+	controller.armStatusMutex.Unlock()
+	return nil
+}
+
+// startTrajectory starts streaming the trajectory to the arm from the next tick
+func (controller *Controller) startTrajectory(t *trajectory.Trajectory) *trajectoryRun {
+	if run := controller.trajectory; run != nil && run.ticker != nil {
+		controller.endTrajectory(api.TrajectoryCanceled)
+	}
+	controller.trajectoryCount++
+	controller.trajectory = &trajectoryRun{
+		id:         fmt.Sprint(controller.trajectoryCount),
+		trajectory: t,
+		started:    time.Now(),
+		ticker:     time.NewTicker(time.Duration(*trajectoryperiod) * time.Millisecond),
+		status:     api.TrajectoryRunning,
+	}
+	log.Printf("[Trajectory] %v started, %v waypoints in %v", controller.trajectory.id, len(t.Waypoints), t.Duration())
+	return controller.trajectory
+}
+
+// stepTrajectory sends the frame of the running trajectory for now, the last one once it is over,
+// and fails it if the frame takes the gripper out of the envelope
+func (controller *Controller) stepTrajectory() {
+	run := controller.trajectory
+	elapsed := time.Since(run.started)
+	if d := run.trajectory.Duration(); elapsed > d {
+		elapsed = d
+	}
+	jp, waypoint := run.trajectory.At(elapsed)
+	pose := RobotPose(jp)
+	// move to the frame within the period
+	delta := byte(time.Duration(*trajectoryperiod) * time.Millisecond / (16 * time.Millisecond))
+	alp, err := pose.BuildArmLinkPacket(delta)
+	// checked ahead at the multiples of the period, the frames are sent a bit after them
+	if err == nil && envelope != nil {
+		err = envelope.CheckPose(jp, reactor())
+	}
+	if err == nil {
+		run.frame = alp
+		err = controller.send(alp)
+	}
+	if err != nil {
+		log.Printf("[Trajectory] %v failed: %v", run.id, err)
+		controller.endTrajectory(api.TrajectoryFailed)
+		return
+	}
+	controller.CurrentRobotPose = &pose
+	run.elapsed, run.waypoint = elapsed, waypoint
+	if waypoint == len(run.trajectory.Waypoints) {
+		controller.endTrajectory(api.TrajectoryDone)
+	}
+}
+
+// endTrajectory stops streaming the running trajectory, the arm stays at the last frame
+func (controller *Controller) endTrajectory(status string) {
+	run := controller.trajectory
+	run.ticker.Stop()
+	run.ticker = nil
+	run.status = status
+	log.Printf("[Trajectory] %v %v at %v", run.id, status, run.elapsed)
+}
+
+// TrajectoryState returns the progress of the latest trajectory
+func (controller *Controller) TrajectoryState() api.TrajectoryState {
+	run := controller.trajectory
+	ts := api.TrajectoryState{
+		ID:            run.id,
+		Status:        run.status,
+		Interpolation: run.trajectory.Interpolation.String(),
+		Waypoint:      run.waypoint,
+		Waypoints:     len(run.trajectory.Waypoints),
+		Elapsed:       int(run.elapsed / time.Millisecond),
+		Duration:      int(run.trajectory.Duration() / time.Millisecond),
+	}
+	if ts.Duration > 0 {
+		ts.Progress = float64(ts.Elapsed) / float64(ts.Duration)
+	}
+	return ts
+}
+
+// perform sends the alp of a motion command moving to the pose or the position, whichever is not nil,
+// or queues it behind the commands in line if queue, and returns the commandRun tracking it
+func (controller *Controller) perform(alp *armlink.ArmLinkPacket, token string, queue bool, pose *RobotPose, position *RobotPosition) *commandRun {
+	controller.commandCount++
+	c := &commandRun{
+		id:             fmt.Sprint(controller.commandCount),
+		owner:          token,
+		frame:          alp,
+		mode:           controller.CurrentMode,
+		beforePose:     *controller.CurrentRobotPose,
+		beforePosition: *controller.CurrentRobotPosition,
+		status:         api.CommandQueued,
+		created:        time.Now(),
+	}
+	controller.commands[c.id] = c
+	delete(controller.commands, fmt.Sprint(controller.commandCount-maxCommands))
+	if pose != nil {
+		controller.CurrentRobotPose = pose
+	}
+	if position != nil {
+		controller.CurrentRobotPosition = position
+	}
+	c.pose, c.position = *controller.CurrentRobotPose, *controller.CurrentRobotPosition
+	// a move not queued takes over the one executing
+	if !queue {
+		controller.cancelCommands(0, fmt.Errorf("superseded by command %v", c.id))
+	}
+	controller.commandLine = append(controller.commandLine, c)
+	if len(controller.commandLine) == 1 {
+		controller.startCommand()
+	}
+	log.Printf("[Command] %v %v: %v", c.id, c.status, alp.String())
+	return c
+}
+
+// startCommand sends the command at the head of the line, and cancels the rest if it fails
+func (controller *Controller) startCommand() {
+	c := controller.commandLine[0]
+	if err := controller.send(c.frame); err != nil {
+		controller.cancelCommands(0, nil)
+		c.status, c.err = api.CommandFailed, err
+		log.Printf("[Command] %v failed: %v", c.id, err)
+		return
+	}
+	c.status, c.started = api.CommandExecuting, time.Now()
+	controller.commandTimer = time.NewTimer(time.Until(c.estimated()))
+}
+
+// stepCommand completes the executing command once the arm is estimated to be there,
+// or the servos stopped moving if they are read, and starts the next one in line
+func (controller *Controller) stepCommand() {
+	c := controller.commandLine[0]
+	controller.commandTimer = nil
+	if telemeter, ok := controller.Transport.(armlink.Telemeter); ok {
+		if states, err := telemeter.Telemetry(); err == nil {
+			c.measured = map[string]int{}
+			moving := false
+			for _, s := range states {
+				// the first servo of a joint drives it, the others mirror it
+				if _, ok := c.measured[s.Joint]; !ok {
+					c.measured[s.Joint] = int(s.Position)
+				}
+				moving = moving || s.Speed != 0
+			}
+			if moving && time.Since(c.estimated()) < commandGrace {
+				controller.commandTimer = time.NewTimer(time.Duration(*trajectoryperiod) * time.Millisecond)
+				return
+			}
+		}
+	}
+	c.status, c.finished = api.CommandCompleted, time.Now()
+	controller.commandLine = controller.commandLine[1:]
+	log.Printf("[Command] %v %v", c.id, c.status)
+	if len(controller.commandLine) > 0 {
+		controller.startCommand()
+	}
+}
+
+// cancelCommands cancels the commands in line from the index on, or fails the one executing with the err if not nil,
+// and brings the commanded pose back to where it was before the first of them not sent yet
+func (controller *Controller) cancelCommands(from int, err error) {
+	line := controller.commandLine
+	if from >= len(line) {
+		return
+	}
+	if from == 0 && controller.commandTimer != nil {
+		controller.commandTimer.Stop()
+		controller.commandTimer = nil
+	}
+	reverted := false
+	for _, c := range line[from:] {
+		if c.status == api.CommandQueued && !reverted {
+			pose, position := c.beforePose, c.beforePosition
+			controller.CurrentRobotPose, controller.CurrentRobotPosition = &pose, &position
+			reverted = true
+		}
+		if c.status == api.CommandExecuting && err != nil {
+			c.status, c.err = api.CommandFailed, err
+		} else {
+			c.status = api.CommandCanceled
+		}
+		c.finished = time.Now()
+		log.Printf("[Command] %v %v", c.id, c.status)
+	}
+	controller.commandLine = line[:from]
+}
+
+// CommandState returns the progress of the command
+func (controller *Controller) CommandState(c *commandRun) api.CommandState {
+	cs := api.CommandState{
+		ID:       c.id,
+		Status:   c.status,
+		Mode:     c.mode.Name(),
+		Created:  c.created,
+		Measured: c.measured,
+	}
+	if c.err != nil {
+		cs.Error = c.err.Error()
+	}
+	if !c.started.IsZero() {
+		started, estimated := c.started, c.estimated()
+		cs.Started, cs.Estimated = &started, &estimated
+	}
+	if !c.finished.IsZero() {
+		finished := c.finished
+		cs.Finished = &finished
+	}
+	if c.mode == armlink.ModeBackhoe {
+		cs.Pose = map[string]int{}
+		for _, l := range armlink.ModeLimits(armlink.ModeBackhoe) {
+			v, _, _ := c.pose.Joint(l.Name)
+			cs.Pose[l.Name] = int(*v)
+		}
+	} else {
+		position := api.PositionState(c.position)
+		cs.Position = &position
+	}
+	return cs
+}
+
+// queued reports if the msg is a motion command asking to wait for the ones in line, and if it can
+func queued(msg api.HandlerMessage) (queue bool, queueable bool) {
+	switch msg.Type {
+	case api.TypePutBase, api.TypePutShoulder, api.TypePutElbow, api.TypePutWristAngle, api.TypePutWristRotation, api.TypePutGripper:
+		c, ok := msg.Value[0].(api.RobotCommand)
+		return ok && c.Queue, true
+	case api.TypePutPose:
+		c, ok := msg.Value[0].(api.PoseCommand)
+		return ok && c.Queue, true
+	case api.TypePatchJoint:
+		c, ok := msg.Value[1].(api.RelativeCommand)
+		return ok && c.Queue, true
+	case api.TypePutCartesian:
+		c, ok := msg.Value[0].(api.CartesianCommand)
+		return ok && c.Queue, true
+	case api.TypePostNamedPose:
+		c, ok := msg.Value[1].(api.GoCommand)
+		return ok && c.Queue, true
+	case api.TypePutPosition:
+		c, ok := msg.Value[0].(api.PositionCommand)
+		return ok && c.Queue, true
+	}
+	return false, false
+}
+
+// commandToken returns the token of the robot command msg, false if not a robot command
+func commandToken(msg api.HandlerMessage) (string, bool) {
+	switch msg.Type {
+	case api.TypePutBase, api.TypePutShoulder, api.TypePutElbow, api.TypePutWristAngle, api.TypePutWristRotation, api.TypePutGripper, api.TypePutReset:
+		c, ok := msg.Value[0].(api.RobotCommand)
+		return c.Token, ok
+	case api.TypePutPose:
+		c, ok := msg.Value[0].(api.PoseCommand)
+		return c.Token, ok
+	case api.TypePatchJoint:
+		c, ok := msg.Value[1].(api.RelativeCommand)
+		return c.Token, ok
+	case api.TypePutCartesian:
+		c, ok := msg.Value[0].(api.CartesianCommand)
+		return c.Token, ok
+	case api.TypePostNamedPose:
+		c, ok := msg.Value[1].(api.GoCommand)
+		return c.Token, ok
+	case api.TypePostTrajectory:
+		c, ok := msg.Value[0].(api.TrajectoryCommand)
+		return c.Token, ok
+	case api.TypePlayRecording:
+		c, ok := msg.Value[1].(api.PlayCommand)
+		return c.Token, ok
+	case api.TypePostProgram:
+		c, ok := msg.Value[0].(api.ProgramCommand)
+		return c.Token, ok
+	case api.TypePutMode:
+		c, ok := msg.Value[0].(api.ModeCommand)
+		return c.Token, ok
+	case api.TypePutPosition:
+		c, ok := msg.Value[0].(api.PositionCommand)
+		return c.Token, ok
+	}
+	return "", false
+}
+
+// finishRecording stops the active recording and saves it, if any
+func (controller *Controller) finishRecording() (*recordings.Recording, error) {
+	rec := controller.recording
+	if rec == nil {
+		return nil, nil
+	}
+	controller.recording = nil
+	rec.Finish(time.Since(controller.recordingStarted))
+	log.Printf("[Recording] %v finished, %v frames in %v ms", rec.ID, rec.Count, rec.Duration)
+	return rec, recordingStore.Save(rec)
+}
+
+// cancelProgram cancels the running program with the status, if any
+func (controller *Controller) cancelProgram(status string) {
+	if run := controller.program; run != nil && run.Running() {
+		run.Cancel(status)
+		log.Printf("[Program] %v %v", run.ID, status)
+	}
+}
+
+// resetUserTimer restarts the inactivity timeout of the CurrentUser
+func (controller *Controller) resetUserTimer() {
+	controller.stopUserTimer()
+	controller.UserTimer.Reset(time.Second * time.Duration(*userTimeout))
+}
+
+// stopUserTimer stops the inactivity timeout, and drains it if it fired before being read
+func (controller *Controller) stopUserTimer() {
+	if !controller.UserTimer.Stop() {
+		select {
+		case <-controller.UserTimer.C:
+		default:
+		}
+	}
+}
+
+// releaseUser saves their recording, cancels their program, puts the arm to sleep and deletes the CurrentUser,
+// once they left or timed out
+func (controller *Controller) releaseUser() {
+	// save the recording of the user, if any
+	if _, err := controller.finishRecording(); err != nil {
+		log.Printf("[Recording] %v", err)
+	}
+	controller.cancelProgram(api.ProgramCanceled)
+	// reset CurrentRobotPose
+	controller.ResetPose()
+	// set the robot in sleep mode
+	alp := &armlink.ArmLinkPacket{}
 	alp.SetExtended(armlink.ExtendedSleep)
-	controller.ArmLinkSerial.Send(alp.Bytes())
+	controller.send(alp)
 	// turn off the light
 	switchLight(false)
+	// delete the current user; assign an empty User
+	controller.CurrentUser = &api.User{}
+}
+
+// Asleep reports if the arm was last put in sleep mode
+func (controller *Controller) Asleep() bool {
+	controller.armStatusMutex.Lock()
+	defer controller.armStatusMutex.Unlock()
+	return controller.asleep
+}
+
+// limits returns the Limits of the joints against the FirmwareLimits, and the home pose
+func limits() api.Limits {
+	l := api.Limits{
+		Joints: map[string]api.JointLimit{},
+		Home:   map[string]int{},
+	}
+	home := homePosition()
+	pose := RobotPose(home)
+	for _, firmware := range armlink.FirmwareLimits {
+		v, limit, _ := pose.Joint(firmware.Name)
+		l.Joints[limit.Name] = api.JointLimit{
+			Min:         limit.Min,
+			Max:         limit.Max,
+			Default:     limit.Default,
+			FirmwareMin: firmware.Min,
+			FirmwareMax: firmware.Max,
+		}
+		l.Home[limit.Name] = int(*v)
+	}
+	return l
+}
+
+// PoseState returns the commanded pose with the limits of the joints
+func (controller *Controller) PoseState() api.PoseState {
+	jointState := func(l armlink.Limit, v uint16) api.JointState {
+		return api.JointState{
+			Value:   int(v),
+			Degrees: calibration[l.Name].FromTicks(int(v), units.Degrees),
+			Radians: calibration[l.Name].FromTicks(int(v), units.Radians),
+			Min:     l.Min,
+			Max:     l.Max,
+			Default: l.Default,
+		}
+	}
+	rp := controller.CurrentRobotPose
+	ps := api.PoseState{
+		Mode:    controller.CurrentMode.Name(),
+		Asleep:  controller.Asleep(),
+		Stopped: controller.Stopped(),
+		Joints: map[string]api.JointState{
+			armlink.BaseLimit.Name:          jointState(armlink.BaseLimit, rp.Base),
+			armlink.ShoulderLimit.Name:      jointState(armlink.ShoulderLimit, rp.Shoulder),
+			armlink.ElbowLimit.Name:         jointState(armlink.ElbowLimit, rp.Elbow),
+			armlink.WristAngleLimit.Name:    jointState(armlink.WristAngleLimit, rp.WristAngle),
+			armlink.WristRotationLimit.Name: jointState(armlink.WristRotationLimit, rp.WristRotation),
+			armlink.GripperLimit.Name:       jointState(armlink.GripperLimit, rp.Gripper),
+		},
+	}
+	if controller.CurrentMode != armlink.ModeBackhoe {
+		position := api.PositionState(*controller.CurrentRobotPosition)
+		ps.Position = &position
+	}
+	return ps
+}
+
+// sendAndConfirm sends the ArmLinkPacket and waits for the arm to respond
+func (controller *Controller) sendAndConfirm(alp *armlink.ArmLinkPacket) (*armlink.ArmLinkResponse, error) {
+	// discard the stale response, if any
+	select {
+	case <-controller.ArmLinkResponseChannel:
+	default:
+	}
+	if err := controller.send(alp); err != nil {
+		return nil, err
+	}
+	select {
+	case alr := <-controller.ArmLinkResponseChannel:
+		return &alr, nil
+	case <-time.After(time.Millisecond * time.Duration(*confirmTimeout)):
+		return nil, errArmNotResponding
+	}
+}
+
+// setMode changes the mode of the arm, which also goes to home,
+// and checks the arm confirmed the change unless confirmTimeout is 0
+func (controller *Controller) setMode(mode armlink.Mode) error {
+	alp := &armlink.ArmLinkPacket{}
+	alp.SetExtended(mode.Extended())
+	if *confirmTimeout == 0 {
+		if err := controller.send(alp); err != nil {
+			return err
+		}
+		controller.CurrentMode = mode
+		return nil
+	}
+	alr, err := controller.sendAndConfirm(alp)
+	if err != nil {
+		log.Printf("[ArmLinkResponse] %v mode not confirmed: %v", mode, err)
+		return err
+	}
+	if alr.Error != 0 {
+		return fmt.Errorf("the arm replied with the error %#02x", alr.Error)
+	}
+	if alr.Mode != mode {
+		return fmt.Errorf("the arm is in %v mode instead of %v mode", alr.Mode, mode)
+	}
+	log.Printf("[ArmLinkResponse] %v mode confirmed: %v", mode, alr.String())
+	controller.CurrentMode = mode
+	return nil
+}
+
+// resetArm sets the robot in Joint mode and go to home
+func (controller *Controller) resetArm() error {
+	return controller.setMode(armlink.ModeBackhoe)
+}
+
+// Connected reports if the link to the arm is up
+func (controller *Controller) Connected() bool {
+	if r, ok := controller.Transport.(armlink.Reconnector); ok {
+		return r.Connected()
+	}
+	return true
+}
+
+// resync brings the arm back to the current mode and pose after the link is back
+func (controller *Controller) resync() {
+	// the firmware may have restarted, hold the arm again
+	if controller.Stopped() {
+		log.Println("[Transport] Resending the stop")
+		alp := &armlink.ArmLinkPacket{}
+		alp.SetExtended(armlink.ExtendedStop)
+		controller.send(alp)
+		return
+	}
+	log.Printf("[Transport] Resyncing the arm in %v mode", controller.CurrentMode)
+	// nobody is using the arm, keep it in sleep mode
+	if controller.CurrentUser.Token == "" {
+		alp := &armlink.ArmLinkPacket{}
+		alp.SetExtended(armlink.ExtendedSleep)
+		controller.send(alp)
+		return
+	}
+	// the firmware may have restarted, set the mode again
+	controller.setMode(controller.CurrentMode)
+	controller.sync()
+}
+
+// receive parses the ArmLinkResponses replied by the arm until the Transport is closed
+func (controller *Controller) receive() {
+	scanner := bufio.NewScanner(armlink.NewTransportReader(controller.Transport))
+	scanner.Split(armlink.ScanArmLinkResponses)
+	for scanner.Scan() {
+		alr, err := armlink.ParseArmLinkResponse(scanner.Bytes())
+		if err != nil {
+			log.Printf("[ArmLinkResponse] %v", err)
+			continue
+		}
+		log.Printf("[ArmLinkResponse] %v", alr.String())
+		controller.armStatusMutex.Lock()
+		controller.LastArmLinkResponse = alr
+		controller.armStatusMutex.Unlock()
+		// pass it to the one waiting for the confirmation
+		select {
+		case controller.ArmLinkResponseChannel <- *alr:
+		default:
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("[ArmLinkResponse] %v", err)
+	}
 }
 
 // NewController creates a new instance of Controller
-func NewController(als *armlink.ArmLinkSerial) *Controller {
+func NewController(transport armlink.Transport) *Controller {
 	hmc := make(chan api.HandlerMessage)
 	controller := Controller{
-		ArmLinkSerial:     als,
-		CurrentRobotPose:  &RobotPose{},
-		CurrentUser:       &api.User{},
-		HandlerChannel:    hmc,
-		LastArmLinkPacket: &armlink.ArmLinkPacket{},
-		UserActChannel:    make(chan bool),
-		UserTimer:         time.NewTimer(time.Second * 10),
-		UserTimerFinish:   make(chan bool),
+		ArmLinkResponseChannel: make(chan armlink.ArmLinkResponse, 1),
+		CurrentMode:            armlink.ModeBackhoe,
+		CurrentRobotPose:       &RobotPose{},
+		CurrentUser:            &api.User{},
+		HandlerChannel:         hmc,
+		LastArmLinkPacket:      &armlink.ArmLinkPacket{},
+		Transport:              transport,
+		UserTimer:              time.NewTimer(time.Second * 10),
+		commands:               map[string]*commandRun{},
 	}
 	controller.ResetPose()
+	controller.ResetPosition()
 	controller.UserTimer.Stop()
 
 	// init
+	// listen to the responses from the arm
+	go controller.receive()
+	// ask the arm to identify itself
+	alp := &armlink.ArmLinkPacket{}
+	alp.SetExtended(armlink.ExtendedArmID)
+	controller.send(alp)
 	// set the robot in sleep mode
-	alp := armlink.ArmLinkPacket{}
+	alp = &armlink.ArmLinkPacket{}
 	alp.SetExtended(armlink.ExtendedSleep)
-	controller.ArmLinkSerial.Send(alp.Bytes())
+	controller.send(alp)
 	// turn off the light
 	switchLight(false)
 
+	// listen to the reconnections of the link, if the transport reconnects
+	var reconnected <-chan struct{}
+	if r, ok := transport.(armlink.Reconnector); ok {
+		reconnected = r.Reconnected()
+	}
+
 	go func() {
 		for {
-			msg, ok := <-hmc
+			var msg api.HandlerMessage
+			var ok bool
+			// stream the running trajectory, if any
+			var tick <-chan time.Time
+			if run := controller.trajectory; run != nil && run.ticker != nil {
+				tick = run.ticker.C
+			}
+			// complete the executing command, if any
+			var done <-chan time.Time
+			if controller.commandTimer != nil {
+				done = controller.commandTimer.C
+			}
+			select {
+			case <-reconnected:
+				controller.resync()
+				continue
+			case <-tick:
+				controller.stepTrajectory()
+				continue
+			case <-done:
+				controller.stepCommand()
+				continue
+			case <-controller.UserTimer.C: // Inactive, logout
+				log.Printf("[UserTimer] Timeout, deleting the user %v", controller.CurrentUser.Name)
+				// post to Slack
+				postToSlack(fmt.Sprintf(`{"text":"<!here> User %v (%v) was inactive for %v seconds, releasing Leubot."}`, controller.CurrentUser.Name, controller.CurrentUser.Email, *userTimeout))
+				controller.releaseUser()
+				continue
+			case msg, ok = <-hmc:
+			}
 			if !ok {
 				break
 			}
 
 			log.Printf("[CurrentRobotPose] %v", controller.CurrentRobotPose.String())
 
-			switch msg.Type {
-			case api.TypeAddUser:
-				userInfo, ok := msg.Value[0].(api.UserInfo)
-				if !ok {
+			// reject the commands of a program canceled since it sent them
+			if run := controller.program; msg.Program != "" && (run == nil || run.ID != msg.Program || !run.Running()) {
+				hmc <- api.HandlerMessage{
+					Type: api.TypeProgramFinished,
+				}
+				continue
+			}
+
+			// reject the robot commands of an invalid token before telling anything of the robot
+			if token, ok := commandToken(msg); ok && token != controller.CurrentUser.Token && token != *mastertoken {
+				hmc <- api.HandlerMessage{
+					Type: api.TypeInvalidToken,
+				}
+				continue
+			}
+			// reject the robot commands until re-armed
+			if msg.Type.IsRobotCommand() && controller.Stopped() {
+				hmc <- api.HandlerMessage{
+					Type: api.TypeStopped,
+				}
+				continue
+			}
+			// reject the robot commands while the link is down
+			if msg.Type.IsRobotCommand() && !controller.Connected() {
+				hmc <- api.HandlerMessage{
+					Type: api.TypeRobotDisconnected,
+				}
+				continue
+			}
+			// hold the robot commands back while commands are queued, unless queued too
+			queue, queueable := queued(msg)
+			if n := len(controller.commandLine) - 1; msg.Type.IsRobotCommand() && n > 0 && !queue {
+				reason := fmt.Sprintf("commands are queued (%v), cancel them first", n)
+				if queueable {
+					reason = fmt.Sprintf("commands are queued (%v), queue this one too or cancel them", n)
+				}
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeCommandsQueued,
+					Value: []interface{}{reason},
+				}
+				continue
+			}
+			if queue && len(controller.commandLine) > maxQueued {
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeCommandsQueued,
+					Value: []interface{}{fmt.Sprintf("the queue is full with %v commands", maxQueued)},
+				}
+				continue
+			}
+			// reject the joint commands outside of Joint mode
+			if msg.Type.IsJointCommand() && controller.CurrentMode != armlink.ModeBackhoe {
+				hmc <- api.HandlerMessage{
+					Type: api.TypeWrongMode,
+				}
+				continue
+			}
+
+			switch msg.Type {
+			case api.TypeAddUser:
+				userInfo, ok := msg.Value[0].(api.UserInfo)
+				if !ok {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeSomethingWentWrong,
 					}
@@ -173,167 +1261,991 @@ func NewController(als *armlink.ArmLinkSerial) *Controller {
 				// check if the email is valid
 				if err := checkmail.ValidateFormat(userInfo.Email); err != nil {
 					hmc <- api.HandlerMessage{
-						Type: api.TypeInvalidUserInfo,
+						Type: api.TypeInvalidUserInfo,
+					}
+					break
+				}
+				// check if the speed is valid
+				if userInfo.Speed != nil && !armlink.SpeedLimit.Contains(*userInfo.Speed) {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidUserInfo,
+					}
+					break
+				}
+				// check if there's no user in the system
+				if controller.CurrentUser.ToUserInfo() != (api.UserInfo{}) && userInfo.Email != controller.CurrentUser.Email {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeUserExisted,
+					}
+					break
+				}
+				// reissue the token for the existing user an return
+				if userInfo.Email == controller.CurrentUser.Email {
+					controller.CurrentUser = api.NewUser(&userInfo)
+					log.Printf("[User] Token reissued for %v", userInfo.Name)
+					if *userTimeout != 0 {
+						controller.resetUserTimer()
+						log.Println("[UserTimer] Timer resetted")
+					}
+					// skip the rest and return the response with the new token
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeUserAdded,
+						Value: []interface{}{*controller.CurrentUser},
+					}
+					break
+				}
+				// save the recording left by the previous user, if any
+				if _, err := controller.finishRecording(); err != nil {
+					log.Printf("[Recording] %v", err)
+				}
+				controller.cancelProgram(api.ProgramCanceled)
+				// set the robot in Joint mode and go to home,
+				// or once re-armed or reconnected if it is stopped or disconnected
+				if err := controller.resetArm(); err == errArmNotResponding {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeArmNotResponding,
+					}
+					break
+				} else if err != nil && err != errStopped && controller.Connected() {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeArmError,
+						Value: []interface{}{err.Error()},
+					}
+					break
+				}
+				// register the user to the system with the new token
+				controller.CurrentUser = api.NewUser(&userInfo)
+				// turn on the light
+				if *miioenabled {
+					cmd := exec.Command(*miiocli, "yeelight", "--ip", *miioip, "--token", *miiotoken, "on")
+					cmd.Run()
+				}
+				// reset CurrentRobotPose
+				controller.ResetPose()
+				// sync with Leubot
+				controller.sync()
+				// post to Slack - stop
+				postToSlack(fmt.Sprintf(`{"text":"<!here> User %v (%v) stopped using Leubot."}`, controller.CurrentUser.Name, controller.CurrentUser.Email))
+				// start the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+					log.Printf("[UserTimer] Started for %v", userInfo.Name)
+				} // End if *userTimeout != 0
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeUserAdded,
+					Value: []interface{}{*controller.CurrentUser},
+				}
+			case api.TypeGetUser:
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeCurrentUser,
+					Value: []interface{}{controller.CurrentUser.ToUserInfo()},
+				}
+			case api.TypeDeleteUser:
+				// receive the token
+				token, ok := msg.Value[0].(string)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if token != controller.CurrentUser.Token && token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeUserNotFound,
+					}
+					break
+				}
+				// stop the timer
+				controller.stopUserTimer()
+				// post to Slack - start
+				postToSlack(fmt.Sprintf(`{"text":"<!here> User %v (%v) started using Leubot."}`, controller.CurrentUser.Name, controller.CurrentUser.Email))
+				controller.releaseUser()
+
+				hmc <- api.HandlerMessage{
+					Type: api.TypeUserDeleted,
+				}
+			case api.TypePutBase:
+				// receive the robotCommand
+				robotCommand, ok := msg.Value[0].(api.RobotCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				// check the value is valid
+				pose, alp, err := controller.buildJoint(armlink.BaseLimit.Name, robotCommand)
+				if err != nil {
+					hmc <- commandError(err)
+					break
+				}
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				// perform the move, or queue it behind the others
+				c := controller.perform(alp, robotCommand.Token, robotCommand.Queue, pose, nil)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.CommandState(c)},
+				}
+			case api.TypePutShoulder:
+				// receive the robotCommand
+				robotCommand, ok := msg.Value[0].(api.RobotCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				// check the value is valid
+				pose, alp, err := controller.buildJoint(armlink.ShoulderLimit.Name, robotCommand)
+				if err != nil {
+					hmc <- commandError(err)
+					break
+				}
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				// perform the move, or queue it behind the others
+				c := controller.perform(alp, robotCommand.Token, robotCommand.Queue, pose, nil)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.CommandState(c)},
+				}
+			case api.TypePutElbow:
+				// receive the robotCommand
+				robotCommand, ok := msg.Value[0].(api.RobotCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				// check the value is valid
+				pose, alp, err := controller.buildJoint(armlink.ElbowLimit.Name, robotCommand)
+				if err != nil {
+					hmc <- commandError(err)
+					break
+				}
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				// perform the move, or queue it behind the others
+				c := controller.perform(alp, robotCommand.Token, robotCommand.Queue, pose, nil)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.CommandState(c)},
+				}
+			case api.TypePutWristAngle:
+				// receive the robotCommand
+				robotCommand, ok := msg.Value[0].(api.RobotCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				// check the value is valid
+				pose, alp, err := controller.buildJoint(armlink.WristAngleLimit.Name, robotCommand)
+				if err != nil {
+					hmc <- commandError(err)
+					break
+				}
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				// perform the move, or queue it behind the others
+				c := controller.perform(alp, robotCommand.Token, robotCommand.Queue, pose, nil)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.CommandState(c)},
+				}
+			case api.TypePutWristRotation:
+				// receive the robotCommand
+				robotCommand, ok := msg.Value[0].(api.RobotCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				// check the value is valid
+				pose, alp, err := controller.buildJoint(armlink.WristRotationLimit.Name, robotCommand)
+				if err != nil {
+					hmc <- commandError(err)
+					break
+				}
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				// perform the move, or queue it behind the others
+				c := controller.perform(alp, robotCommand.Token, robotCommand.Queue, pose, nil)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.CommandState(c)},
+				}
+			case api.TypePutGripper:
+				// receive the robotCommand
+				robotCommand, ok := msg.Value[0].(api.RobotCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				// check the value is valid
+				pose, alp, err := controller.buildJoint(armlink.GripperLimit.Name, robotCommand)
+				if err != nil {
+					hmc <- commandError(err)
+					break
+				}
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				// perform the move, or queue it behind the others
+				c := controller.perform(alp, robotCommand.Token, robotCommand.Queue, pose, nil)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.CommandState(c)},
+				}
+			case api.TypePutPose:
+				// receive the poseCommand
+				poseCommand, ok := msg.Value[0].(api.PoseCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if poseCommand.Token != controller.CurrentUser.Token && poseCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				// apply the given values to the current pose
+				pose := *controller.CurrentRobotPose
+				err := setJoints(&pose, poseCommand.Unit, poseCommand.Base, poseCommand.Shoulder, poseCommand.Elbow, poseCommand.WristAngle, poseCommand.WristRotation, poseCommand.Gripper)
+				// check the values are valid together
+				var alp *armlink.ArmLinkPacket
+				if err == nil {
+					alp, err = controller.buildPose(&pose, poseCommand.Speed)
+				}
+				if err != nil {
+					hmc <- commandError(err)
+					break
+				}
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				// perform the move, or queue it behind the others
+				c := controller.perform(alp, poseCommand.Token, poseCommand.Queue, &pose, nil)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.CommandState(c)},
+				}
+			case api.TypePatchJoint:
+				// receive the joint and the relativeCommand
+				joint, ok := msg.Value[0].(string)
+				relativeCommand, ok2 := msg.Value[1].(api.RelativeCommand)
+				if !ok || !ok2 {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if relativeCommand.Token != controller.CurrentUser.Token && relativeCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				pose := *controller.CurrentRobotPose
+				value, limit, ok := pose.Joint(joint)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				unit, err := units.ParseUnit(relativeCommand.Unit)
+				if err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeInvalidCommand,
+						Value: []interface{}{err.Error()},
+					}
+					break
+				}
+				// clamp or reject the values beyond the limits
+				target := int(*value) + calibration[joint].DeltaTicks(relativeCommand.Delta, unit)
+				clamped := false
+				if !limit.Contains(target) {
+					if !*clamp {
+						hmc <- api.HandlerMessage{
+							Type:  api.TypeInvalidCommand,
+							Value: []interface{}{limit.Check(armlink.ModeBackhoe, target).Error()},
+						}
+						break
+					}
+					if target < limit.Min {
+						target = limit.Min
+					} else {
+						target = limit.Max
+					}
+					clamped = true
+				}
+				*value = uint16(target)
+				alp, err := controller.buildPose(&pose, relativeCommand.Speed)
+				if err != nil {
+					hmc <- commandError(err)
+					break
+				}
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				// perform the move, or queue it behind the others
+				c := controller.perform(alp, relativeCommand.Token, relativeCommand.Queue, &pose, nil)
+
+				hmc <- api.HandlerMessage{
+					Type: api.TypeActionPerformed,
+					Value: []interface{}{api.RelativeResult{
+						Joint:   joint,
+						Value:   target,
+						Degrees: calibration[joint].FromTicks(target, units.Degrees),
+						Radians: calibration[joint].FromTicks(target, units.Radians),
+						Clamped: clamped,
+						Command: c.id,
+					}, controller.CommandState(c)},
+				}
+			case api.TypePutCartesian:
+				// receive the cartesianCommand
+				cartesianCommand, ok := msg.Value[0].(api.CartesianCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if cartesianCommand.Token != controller.CurrentUser.Token && cartesianCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				// apply the given values to the current position of the gripper
+				current := armlink.JointPosition(*controller.CurrentRobotPose)
+				target := reactor().Forward(current)
+				if cartesianCommand.X != nil {
+					target.X = *cartesianCommand.X
+				}
+				if cartesianCommand.Y != nil {
+					target.Y = *cartesianCommand.Y
+				}
+				if cartesianCommand.Z != nil {
+					target.Z = *cartesianCommand.Z
+				}
+				if cartesianCommand.Pitch != nil {
+					target.Pitch = *cartesianCommand.Pitch * math.Pi / 180
+				}
+				// solve the joints nearest to the current ones
+				var alp *armlink.ArmLinkPacket
+				jp, err := reactor().Inverse(target, current)
+				pose := RobotPose(jp)
+				if err == nil {
+					alp, err = controller.buildPose(&pose, cartesianCommand.Speed)
+				}
+				if err != nil {
+					hmc <- commandError(err)
+					break
+				}
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				// perform the move, or queue it behind the others
+				c := controller.perform(alp, cartesianCommand.Token, cartesianCommand.Queue, &pose, nil)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.CommandState(c), pose.String()},
+				}
+			case api.TypeGetLimits:
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeLimits,
+					Value: []interface{}{limits()},
+				}
+			case api.TypeGetPoses:
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeNamedPoses,
+					Value: []interface{}{poseStore.List()},
+				}
+			case api.TypePutNamedPose:
+				// receive the name and the namedPoseCommand
+				name, ok := msg.Value[0].(string)
+				namedPoseCommand, ok2 := msg.Value[1].(api.NamedPoseCommand)
+				if !ok || !ok2 {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				admin := namedPoseCommand.Token == *mastertoken
+				if namedPoseCommand.Token != controller.CurrentUser.Token && !admin {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				// the current pose is stale in the IK modes
+				if controller.CurrentMode != armlink.ModeBackhoe {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeWrongMode,
+					}
+					break
+				}
+				// check the name is valid
+				if err := poses.CheckName(name); err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeInvalidCommand,
+						Value: []interface{}{err.Error()},
+					}
+					break
+				}
+				// apply the given values to the current pose
+				pose := *controller.CurrentRobotPose
+				if err := setJoints(&pose, namedPoseCommand.Unit, namedPoseCommand.Base, namedPoseCommand.Shoulder, namedPoseCommand.Elbow, namedPoseCommand.WristAngle, namedPoseCommand.WristRotation, namedPoseCommand.Gripper); err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeInvalidCommand,
+						Value: []interface{}{err.Error()},
+					}
+					break
+				}
+				// a pose out of the envelope could not be gone to
+				if envelope != nil {
+					if err := envelope.CheckPose(armlink.JointPosition(pose), reactor()); err != nil {
+						hmc <- commandError(err)
+						break
+					}
+				}
+				namedPose := poses.NewPose(name, armlink.JointPosition(pose), namedPoseCommand.ReadOnly)
+				err := poseStore.Save(namedPose, admin)
+				switch {
+				case err == poses.ErrReadOnly:
+					hmc <- api.HandlerMessage{
+						Type: api.TypePoseReadOnly,
+					}
+				case err != nil:
+					// failed to write the file
+					log.Printf("[Poses] %v", err)
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeSomethingWentWrong,
+						Value: []interface{}{err.Error()},
+					}
+				default:
+					log.Printf("[Poses] Saved %v: %v", name, pose.String())
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeActionPerformed,
+						Value: []interface{}{namedPose},
+					}
+				}
+			case api.TypePostNamedPose:
+				// receive the name and the goCommand
+				name, ok := msg.Value[0].(string)
+				goCommand, ok2 := msg.Value[1].(api.GoCommand)
+				if !ok || !ok2 {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if goCommand.Token != controller.CurrentUser.Token && goCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				namedPose, ok := poseStore.Get(name)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypePoseNotFound,
+					}
+					break
+				}
+				pose := RobotPose(namedPose.JointPosition())
+				alp, err := controller.buildPose(&pose, goCommand.Speed)
+				if err != nil {
+					hmc <- commandError(err)
+					break
+				}
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				// perform the move, or queue it behind the others
+				c := controller.perform(alp, goCommand.Token, goCommand.Queue, &pose, nil)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.CommandState(c)},
+				}
+			case api.TypePostTrajectory:
+				// receive the trajectoryCommand
+				trajectoryCommand, ok := msg.Value[0].(api.TrajectoryCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if trajectoryCommand.Token != controller.CurrentUser.Token && trajectoryCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				// check the waypoints are valid
+				t, err := buildTrajectory(*controller.CurrentRobotPose, trajectoryCommand)
+				if err == nil {
+					err = checkTrajectory(t)
+				}
+				if err != nil {
+					hmc <- commandError(err)
+					break
+				}
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				controller.startTrajectory(t)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeTrajectoryStarted,
+					Value: []interface{}{controller.TrajectoryState()},
+				}
+			case api.TypeGetTrajectory:
+				id, ok := msg.Value[0].(string)
+				if !ok || controller.trajectory == nil || controller.trajectory.id != id {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeTrajectoryNotFound,
+					}
+					break
+				}
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeCurrentTrajectory,
+					Value: []interface{}{controller.TrajectoryState()},
+				}
+			case api.TypeDeleteTrajectory:
+				// receive the id and the token
+				id, ok := msg.Value[0].(string)
+				token, ok2 := msg.Value[1].(string)
+				if !ok || !ok2 {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if token != controller.CurrentUser.Token && token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				if controller.trajectory == nil || controller.trajectory.id != id {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeTrajectoryNotFound,
+					}
+					break
+				}
+				if controller.trajectory.ticker == nil {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeTrajectoryFinished,
+					}
+					break
+				}
+				controller.endTrajectory(api.TrajectoryCanceled)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.TrajectoryState()},
+				}
+			case api.TypePostProgram:
+				// receive the programCommand
+				programCommand, ok := msg.Value[0].(api.ProgramCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if programCommand.Token != controller.CurrentUser.Token && programCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				if run := controller.program; run != nil && run.Running() {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeProgramRunning,
+						Value: []interface{}{fmt.Sprintf("program %v is running", run.ID)},
+					}
+					break
+				}
+				// the moves of the program are checked as they are sent with the token
+				run, err := programs.Compile(fmt.Sprint(controller.programCount+1), programCommand.Token, programCommand.Source)
+				if err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeInvalidCommand,
+						Value: []interface{}{err.Error()},
+					}
+					break
+				}
+				controller.programCount++
+				controller.program = run
+				run.Start(programs.Limits{
+					Steps:   *programsteps,
+					Timeout: time.Duration(*programtimeout) * time.Second,
+				})
+				log.Printf("[Program] %v started", run.ID)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeProgramStarted,
+					Value: []interface{}{run.State()},
+				}
+			case api.TypeGetProgram:
+				id, ok := msg.Value[0].(string)
+				if !ok || controller.program == nil || controller.program.ID != id {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeProgramNotFound,
+					}
+					break
+				}
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeCurrentProgram,
+					Value: []interface{}{controller.program.State()},
+				}
+			case api.TypeDeleteProgram:
+				// receive the id and the token
+				id, ok := msg.Value[0].(string)
+				token, ok2 := msg.Value[1].(string)
+				if !ok || !ok2 {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				if controller.program == nil || controller.program.ID != id {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeProgramNotFound,
+					}
+					break
+				}
+				// only the owner or an admin cancels it
+				if token != controller.program.Owner && token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				if !controller.program.Running() {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeProgramFinished,
+					}
+					break
+				}
+				controller.cancelProgram(api.ProgramCanceled)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.program.State()},
+				}
+			case api.TypePostRecording:
+				// receive the recordingCommand
+				recordingCommand, ok := msg.Value[0].(api.RecordingCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if recordingCommand.Token != controller.CurrentUser.Token && recordingCommand.Token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
+					}
+					break
+				}
+				// only the joint moves are recorded
+				if controller.CurrentMode != armlink.ModeBackhoe {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeWrongMode,
+					}
+					break
+				}
+				if rec := controller.recording; rec != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeRecordingActive,
+						Value: []interface{}{fmt.Sprintf("recording %v is active", rec.ID)},
+					}
+					break
+				}
+				name := recordingCommand.Name
+				if name == "" {
+					name = fmt.Sprintf("recording by %v", controller.CurrentUser.Name)
+				}
+				if err := recordings.CheckName(name); err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeInvalidCommand,
+						Value: []interface{}{err.Error()},
+					}
+					break
+				}
+				// start from the current pose
+				controller.recording = recordings.New(recordingStore.NextID(), name, controller.CurrentUser.Name, armlink.JointPosition(*controller.CurrentRobotPose))
+				controller.recordingStarted = time.Now()
+				log.Printf("[Recording] %v started", controller.recording.ID)
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeRecordingStarted,
+					Value: []interface{}{controller.recording.ID, controller.recording.Info},
+				}
+			case api.TypeStopRecording:
+				// receive the id and the token
+				id, ok := msg.Value[0].(string)
+				token, ok2 := msg.Value[1].(string)
+				if !ok || !ok2 {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// check if the token is valid
+				if token != controller.CurrentUser.Token && token != *mastertoken {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeInvalidToken,
 					}
 					break
 				}
-				// check if there's no user in the system
-				if controller.CurrentUser.ToUserInfo() != (api.UserInfo{}) && userInfo.Email != controller.CurrentUser.Email {
+				if controller.recording == nil || controller.recording.ID != id {
 					hmc <- api.HandlerMessage{
-						Type: api.TypeUserExisted,
+						Type: api.TypeRecordingNotFound,
 					}
 					break
 				}
-				// reissue the token for the existing user an return
-				if userInfo.Email == controller.CurrentUser.Email {
-					controller.CurrentUser = api.NewUser(&userInfo)
-					log.Printf("[User] Token reissued for %v", userInfo.Name)
-					controller.UserTimer.Reset(time.Second * time.Duration(*userTimeout))
-					log.Println("[UserTimer] Timer resetted")
-					// skip the rest and return the response with the new token
+				rec, err := controller.finishRecording()
+				if err != nil {
 					hmc <- api.HandlerMessage{
-						Type:  api.TypeUserAdded,
-						Value: []interface{}{*controller.CurrentUser},
+						Type:  api.TypeSomethingWentWrong,
+						Value: []interface{}{err.Error()},
 					}
 					break
 				}
-				// register the user to the system with the new token
-				controller.CurrentUser = api.NewUser(&userInfo)
-				// turn on the light
-				if *miioenabled {
-					cmd := exec.Command(*miiocli, "yeelight", "--ip", *miioip, "--token", *miiotoken, "on")
-					cmd.Run()
-				}
-				// set the robot in Joint mode and go to home
-				alp := &armlink.ArmLinkPacket{}
-				alp.SetExtended(armlink.ExtendedReset)
-				controller.ArmLinkSerial.Send(alp.Bytes())
-				// reset CurrentRobotPose
-				controller.ResetPose()
-				// sync with Leubot
-				alp = controller.CurrentRobotPose.BuildArmLinkPacket()
-				controller.ArmLinkSerial.Send(alp.Bytes())
-				log.Printf("[ArmLinkPacket] %v", alp.String())
-				// post to Slack - stop
-				postToSlack(fmt.Sprintf(`{"text":"<!here> User %v (%v) stopped using Leubot."}`, controller.CurrentUser.Name, controller.CurrentUser.Email))
-				// start the timer
-				if *userTimeout != 0 {
-					controller.UserTimer.Reset(time.Second * time.Duration(*userTimeout))
-					log.Printf("[UserTimer] Started for %v", userInfo.Name)
-					go func() {
-						for {
-							select {
-							case <-controller.UserActChannel: // Upon any activity, reset the timer
-								log.Println("[UserTimer] Activity detected, resetting the timer")
-								controller.UserTimer.Reset(time.Second * time.Duration(*userTimeout))
-							case <-controller.UserTimer.C: // Inactive, logout
-								log.Printf("[UserTimer] Timeout, deleting the user %v", controller.CurrentUser.Name)
-								// reset CurrentRobotPose
-								controller.ResetPose()
-								// set the robot in sleep mode
-								alp := armlink.ArmLinkPacket{}
-								alp.SetExtended(armlink.ExtendedSleep)
-								controller.ArmLinkSerial.Send(alp.Bytes())
-								// turn off the light
-								switchLight(false)
-								// post to Slack
-								postToSlack(fmt.Sprintf(`{"text":"<!here> User %v (%v) was inactive for %v seconds, releasing Leubot."}`, controller.CurrentUser.Name, controller.CurrentUser.Email, *userTimeout))
-								// delete the current user; assign an empty User
-								controller.CurrentUser = &api.User{}
-								// exiting timer channel listener
-								return
-							case <-controller.UserTimerFinish:
-								log.Println("[UserTimer] User deleted, terminating the timer")
-								return
-							}
-						}
-					}()
-				} // End if *userTimeout != 0
 
 				hmc <- api.HandlerMessage{
-					Type:  api.TypeUserAdded,
-					Value: []interface{}{*controller.CurrentUser},
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{rec.Info},
+				}
+			case api.TypeGetRecordings:
+				list := recordingStore.List()
+				if rec := controller.recording; rec != nil {
+					list = append(list, rec.Info)
 				}
-			case api.TypeGetUser:
 				hmc <- api.HandlerMessage{
-					Type:  api.TypeCurrentUser,
-					Value: []interface{}{controller.CurrentUser.ToUserInfo()},
+					Type:  api.TypeRecordings,
+					Value: []interface{}{list},
 				}
-			case api.TypeDeleteUser:
-				// receive the token
-				token, ok := msg.Value[0].(string)
+			case api.TypeGetRecording:
+				id, _ := msg.Value[0].(string)
+				if rec := controller.recording; rec != nil && rec.ID == id {
+					// the frames keep growing while the handler marshals them
+					active := *rec
+					active.Frames = append([]recordings.Frame{}, rec.Frames...)
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeCurrentRecording,
+						Value: []interface{}{active},
+					}
+					break
+				}
+				rec, ok := recordingStore.Get(id)
 				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeRecordingNotFound,
+					}
+					break
+				}
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeCurrentRecording,
+					Value: []interface{}{*rec},
+				}
+			case api.TypePatchRecording:
+				// receive the id and the recordingCommand
+				id, ok := msg.Value[0].(string)
+				recordingCommand, ok2 := msg.Value[1].(api.RecordingCommand)
+				if !ok || !ok2 {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeSomethingWentWrong,
 					}
 					break
 				}
 				// check if the token is valid
-				if token != controller.CurrentUser.Token && token != *mastertoken {
+				if recordingCommand.Token != controller.CurrentUser.Token && recordingCommand.Token != *mastertoken {
 					hmc <- api.HandlerMessage{
-						Type: api.TypeUserNotFound,
+						Type: api.TypeInvalidToken,
 					}
 					break
 				}
-				// stop the timer
-				if *userTimeout != 0 {
-					controller.UserTimer.Stop()
-					controller.UserTimerFinish <- true
+				if err := recordings.CheckName(recordingCommand.Name); err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeInvalidCommand,
+						Value: []interface{}{err.Error()},
+					}
+					break
+				}
+				// the active recording is saved with its name once stopped
+				if rec := controller.recording; rec != nil && rec.ID == id {
+					rec.Name = recordingCommand.Name
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeActionPerformed,
+						Value: []interface{}{rec.Info},
+					}
+					break
+				}
+				if _, ok := recordingStore.Get(id); !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeRecordingNotFound,
+					}
+					break
+				}
+				info, err := recordingStore.Rename(id, recordingCommand.Name)
+				if err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeSomethingWentWrong,
+						Value: []interface{}{err.Error()},
+					}
+					break
 				}
-				// reset CurrentRobotPose
-				controller.ResetPose()
-				// set the robot in sleep mode
-				alp := armlink.ArmLinkPacket{}
-				alp.SetExtended(armlink.ExtendedSleep)
-				controller.ArmLinkSerial.Send(alp.Bytes())
-				// turn off the light
-				switchLight(false)
-				// post to Slack - start
-				postToSlack(fmt.Sprintf(`{"text":"<!here> User %v (%v) started using Leubot."}`, controller.CurrentUser.Name, controller.CurrentUser.Email))
-				// delete the current user; assign an empty User
-				controller.CurrentUser = &api.User{}
 
 				hmc <- api.HandlerMessage{
-					Type: api.TypeUserDeleted,
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{info},
 				}
-			case api.TypePutBase:
-				// receive the robotCommand
-				robotCommand, ok := msg.Value[0].(api.RobotCommand)
-				if !ok {
+			case api.TypePlayRecording:
+				// receive the id and the playCommand
+				id, ok := msg.Value[0].(string)
+				playCommand, ok2 := msg.Value[1].(api.PlayCommand)
+				if !ok || !ok2 {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeSomethingWentWrong,
 					}
 					break
 				}
 				// check if the token is valid
-				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+				if playCommand.Token != controller.CurrentUser.Token && playCommand.Token != *mastertoken {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeInvalidToken,
 					}
 					break
 				}
-				// check the value is valid
-				if robotCommand.Value < 0 || 1023 < robotCommand.Value {
+				rec, ok := recordingStore.Get(id)
+				if !ok {
 					hmc <- api.HandlerMessage{
-						Type: api.TypeInvalidCommand,
+						Type: api.TypeRecordingNotFound,
+					}
+					break
+				}
+				rate := 1.0
+				if playCommand.Rate != nil {
+					rate = *playCommand.Rate
+				}
+				if err := recordings.CheckRate(rate); err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeInvalidCommand,
+						Value: []interface{}{err.Error()},
 					}
 					break
 				}
+				t := rec.Trajectory(armlink.JointPosition(*controller.CurrentRobotPose), rate)
+				if err := checkTrajectory(t); err != nil {
+					hmc <- commandError(err)
+					break
+				}
 				// ack the timer
 				if *userTimeout != 0 {
-					controller.UserActChannel <- true
+					controller.resetUserTimer()
 				}
-				// set the value to CurrentRobotPose
-				controller.CurrentRobotPose.Base = robotCommand.Value
-				// perform the move
-				alp := controller.CurrentRobotPose.BuildArmLinkPacket()
-				controller.ArmLinkSerial.Send(alp.Bytes())
-				log.Printf("[ArmLinkPacket] %v", alp.String())
+				controller.startTrajectory(t)
 
 				hmc <- api.HandlerMessage{
-					Type: api.TypeActionPerformed,
+					Type:  api.TypeTrajectoryStarted,
+					Value: []interface{}{controller.TrajectoryState()},
 				}
-			case api.TypePutShoulder:
+			case api.TypePutReset:
 				// receive the robotCommand
 				robotCommand, ok := msg.Value[0].(api.RobotCommand)
 				if !ok {
@@ -349,30 +2261,34 @@ func NewController(als *armlink.ArmLinkSerial) *Controller {
 					}
 					break
 				}
-				// check the value is valid
-				if robotCommand.Value < 205 || 810 < robotCommand.Value {
+				// ack the timer
+				if *userTimeout != 0 {
+					controller.resetUserTimer()
+				}
+				// perform the reset
+				if err := controller.resetArm(); err == errArmNotResponding {
 					hmc <- api.HandlerMessage{
-						Type: api.TypeInvalidCommand,
+						Type: api.TypeArmNotResponding,
+					}
+					break
+				} else if err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeArmError,
+						Value: []interface{}{err.Error()},
 					}
 					break
 				}
-				// ack the timer
-				if *userTimeout != 0 {
-					controller.UserActChannel <- true
-				}
-				// set the value to CurrentRobotPose
-				controller.CurrentRobotPose.Shoulder = robotCommand.Value
-				// perform the move
-				alp := controller.CurrentRobotPose.BuildArmLinkPacket()
-				controller.ArmLinkSerial.Send(alp.Bytes())
-				log.Printf("[ArmLinkPacket] %v", alp.String())
+				// reset CurrentRobotPose
+				controller.ResetPose()
+				// sync with Leubot
+				controller.sync()
 
 				hmc <- api.HandlerMessage{
 					Type: api.TypeActionPerformed,
 				}
-			case api.TypePutElbow:
-				// receive the robotCommand
-				robotCommand, ok := msg.Value[0].(api.RobotCommand)
+			case api.TypePutMode:
+				// receive the modeCommand
+				modeCommand, ok := msg.Value[0].(api.ModeCommand)
 				if !ok {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeSomethingWentWrong,
@@ -380,36 +2296,57 @@ func NewController(als *armlink.ArmLinkSerial) *Controller {
 					break
 				}
 				// check if the token is valid
-				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+				if modeCommand.Token != controller.CurrentUser.Token && modeCommand.Token != *mastertoken {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeInvalidToken,
 					}
 					break
 				}
-				// check the value is valid
-				if robotCommand.Value < 210 || 900 < robotCommand.Value {
+				// check the mode is valid
+				mode, err := armlink.ParseMode(modeCommand.Mode)
+				if err != nil {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeInvalidCommand,
 					}
 					break
 				}
+				// the positions of the IK modes are not checked against the envelope
+				if envelope != nil && mode != armlink.ModeBackhoe {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeUnsafePose,
+						Value: []interface{}{fmt.Sprintf("the %v mode is not checked against the safety envelope", modeCommand.Mode)},
+					}
+					break
+				}
 				// ack the timer
 				if *userTimeout != 0 {
-					controller.UserActChannel <- true
+					controller.resetUserTimer()
+				}
+				// change the mode
+				if err := controller.setMode(mode); err == errArmNotResponding {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeArmNotResponding,
+					}
+					break
+				} else if err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeArmError,
+						Value: []interface{}{err.Error()},
+					}
+					break
 				}
-				// set the value to CurrentRobotPose
-				controller.CurrentRobotPose.Elbow = robotCommand.Value
-				// perform the move
-				alp := controller.CurrentRobotPose.BuildArmLinkPacket()
-				controller.ArmLinkSerial.Send(alp.Bytes())
-				log.Printf("[ArmLinkPacket] %v", alp.String())
+				// the arm went to home
+				controller.ResetPose()
+				controller.ResetPosition()
+				// sync with Leubot
+				controller.sync()
 
 				hmc <- api.HandlerMessage{
 					Type: api.TypeActionPerformed,
 				}
-			case api.TypePutWristAngle:
-				// receive the robotCommand
-				robotCommand, ok := msg.Value[0].(api.RobotCommand)
+			case api.TypePutPosition:
+				// receive the positionCommand
+				positionCommand, ok := msg.Value[0].(api.PositionCommand)
 				if !ok {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeSomethingWentWrong,
@@ -417,73 +2354,173 @@ func NewController(als *armlink.ArmLinkSerial) *Controller {
 					break
 				}
 				// check if the token is valid
-				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+				if positionCommand.Token != controller.CurrentUser.Token && positionCommand.Token != *mastertoken {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeInvalidToken,
 					}
 					break
 				}
-				// check the value is valid
-				if robotCommand.Value < 200 || 830 < robotCommand.Value {
+				// check the arm is in one of the IK modes
+				if !controller.CurrentMode.IsCartesian() && !controller.CurrentMode.IsCylindrical() {
 					hmc <- api.HandlerMessage{
-						Type: api.TypeInvalidCommand,
+						Type: api.TypeWrongMode,
+					}
+					break
+				}
+				// apply the given values to the current position
+				position := *controller.CurrentRobotPosition
+				if positionCommand.X != nil {
+					position.X = *positionCommand.X
+				}
+				if positionCommand.Y != nil {
+					position.Y = *positionCommand.Y
+				}
+				if positionCommand.Z != nil {
+					position.Z = *positionCommand.Z
+				}
+				if positionCommand.Base != nil {
+					position.Base = *positionCommand.Base
+				}
+				if positionCommand.WristAngle != nil {
+					position.WristAngle = *positionCommand.WristAngle
+				}
+				if positionCommand.WristRotation != nil {
+					position.WristRotation = *positionCommand.WristRotation
+				}
+				if positionCommand.Gripper != nil {
+					position.Gripper = *positionCommand.Gripper
+				}
+				// check the values and the speed are valid
+				var alp *armlink.ArmLinkPacket
+				delta, err := controller.delta(positionCommand.Speed)
+				if err == nil {
+					alp, err = position.BuildArmLinkPacket(controller.CurrentMode, delta)
+				}
+				if err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeInvalidCommand,
+						Value: []interface{}{err.Error()},
 					}
 					break
 				}
 				// ack the timer
 				if *userTimeout != 0 {
-					controller.UserActChannel <- true
+					controller.resetUserTimer()
 				}
-				// set the value to CurrentRobotPose
-				controller.CurrentRobotPose.WristAngle = robotCommand.Value
-				// perform the move
-				alp := controller.CurrentRobotPose.BuildArmLinkPacket()
-				controller.ArmLinkSerial.Send(alp.Bytes())
-				log.Printf("[ArmLinkPacket] %v", alp.String())
+				// perform the move, or queue it behind the others
+				c := controller.perform(alp, positionCommand.Token, positionCommand.Queue, nil, &position)
 
 				hmc <- api.HandlerMessage{
-					Type: api.TypeActionPerformed,
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.CommandState(c)},
 				}
-			case api.TypePutWristRotation:
-				// receive the robotCommand
-				robotCommand, ok := msg.Value[0].(api.RobotCommand)
+			case api.TypeGetCommand:
+				// receive the id
+				id, ok := msg.Value[0].(string)
 				if !ok {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeSomethingWentWrong,
 					}
 					break
 				}
-				// check if the token is valid
-				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+				c, ok := controller.commands[id]
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeCommandNotFound,
+					}
+					break
+				}
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeCurrentCommand,
+					Value: []interface{}{controller.CommandState(c)},
+				}
+			case api.TypeDeleteCommand:
+				// receive the id and the token
+				id, ok := msg.Value[0].(string)
+				token, ok2 := msg.Value[1].(string)
+				if !ok || !ok2 {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				c, ok := controller.commands[id]
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeCommandNotFound,
+					}
+					break
+				}
+				// check if the token is the one of the command
+				if token != c.owner && token != *mastertoken {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeInvalidToken,
 					}
 					break
 				}
-				// check the value is valid
-				if robotCommand.Value < 0 || 1023 < robotCommand.Value {
+				if c.status != api.CommandQueued {
 					hmc <- api.HandlerMessage{
-						Type: api.TypeInvalidCommand,
+						Type:  api.TypeCommandNotQueued,
+						Value: []interface{}{fmt.Sprintf("the command is %v, only a queued one can be canceled", c.status)},
 					}
 					break
 				}
-				// ack the timer
-				if *userTimeout != 0 {
-					controller.UserActChannel <- true
+				// cancel it and the ones queued after it, which move from its pose
+				for i, queued := range controller.commandLine {
+					if queued == c {
+						controller.cancelCommands(i, nil)
+						break
+					}
+				}
+
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeActionPerformed,
+					Value: []interface{}{controller.CommandState(c)},
+				}
+			case api.TypePutStop:
+				// receive the stopCommand
+				stopCommand, ok := msg.Value[0].(api.StopCommand)
+				if !ok {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeSomethingWentWrong,
+					}
+					break
+				}
+				// latch the stop first so that nothing else moves the arm
+				controller.setStopped(true)
+				if run := controller.trajectory; run != nil && run.ticker != nil {
+					controller.endTrajectory(api.TrajectoryStopped)
+				}
+				controller.cancelProgram(api.ProgramStopped)
+				// the command executing is partly done
+				controller.cancelCommands(0, errors.New("stopped"))
+				alp := &armlink.ArmLinkPacket{}
+				alp.SetExtended(armlink.ExtendedStop)
+				err := controller.send(alp)
+				log.Printf("[Stop] Emergency stop from %v: %v", stopCommand.Source, stopCommand.Reason)
+				// post to Slack
+				who := stopCommand.Source
+				if stopCommand.Token != "" && stopCommand.Token == controller.CurrentUser.Token {
+					who = fmt.Sprintf("%v (%v)", controller.CurrentUser.Name, controller.CurrentUser.Email)
+				}
+				text := fmt.Sprintf("<!here> Emergency stop of Leubot by %v", who)
+				if stopCommand.Reason != "" {
+					text += ": " + stopCommand.Reason
+				}
+				postTextToSlack(text + ". Motion is disabled until re-armed.")
+				if err != nil {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeRobotDisconnected,
+					}
+					break
 				}
-				// set the value to CurrentRobotPose
-				controller.CurrentRobotPose.WristRotation = robotCommand.Value
-				// perform the move
-				alp := controller.CurrentRobotPose.BuildArmLinkPacket()
-				controller.ArmLinkSerial.Send(alp.Bytes())
-				log.Printf("[ArmLinkPacket] %v", alp.String())
 
 				hmc <- api.HandlerMessage{
 					Type: api.TypeActionPerformed,
 				}
-			case api.TypePutGripper:
-				// receive the robotCommand
-				robotCommand, ok := msg.Value[0].(api.RobotCommand)
+			case api.TypePutRearm:
+				// receive the token
+				token, ok := msg.Value[0].(string)
 				if !ok {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeSomethingWentWrong,
@@ -491,66 +2528,85 @@ func NewController(als *armlink.ArmLinkSerial) *Controller {
 					break
 				}
 				// check if the token is valid
-				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+				if token == "" || (token != controller.CurrentUser.Token && token != *mastertoken) {
 					hmc <- api.HandlerMessage{
 						Type: api.TypeInvalidToken,
 					}
 					break
 				}
-				// check the value is valid
-				if robotCommand.Value < 0 || 512 < robotCommand.Value {
+				// check the arm is stopped
+				if !controller.Stopped() {
 					hmc <- api.HandlerMessage{
-						Type: api.TypeInvalidCommand,
+						Type: api.TypeNotStopped,
 					}
 					break
 				}
-				// ack the timer
-				if *userTimeout != 0 {
-					controller.UserActChannel <- true
+				// ack the timer, if the user is there
+				if *userTimeout != 0 && controller.CurrentUser.Token != "" {
+					controller.resetUserTimer()
 				}
-				// set the value to CurrentRobotPose
-				controller.CurrentRobotPose.Gripper = robotCommand.Value
-				// perform the move
-				alp := controller.CurrentRobotPose.BuildArmLinkPacket()
-				controller.ArmLinkSerial.Send(alp.Bytes())
-				log.Printf("[ArmLinkPacket] %v", alp.String())
+				// release the stop, the arm stays where it is until the next command
+				controller.setStopped(false)
+				log.Println("[Stop] Re-armed")
+				postTextToSlack("Leubot was re-armed.")
 
 				hmc <- api.HandlerMessage{
 					Type: api.TypeActionPerformed,
 				}
-			case api.TypePutReset:
-				// receive the robotCommand
-				robotCommand, ok := msg.Value[0].(api.RobotCommand)
+			case api.TypeGetPose:
+				hmc <- api.HandlerMessage{
+					Type:  api.TypeCurrentPose,
+					Value: []interface{}{controller.PoseState()},
+				}
+			case api.TypeGetCartesian:
+				// the pose is stale in the IK modes
+				if controller.CurrentMode != armlink.ModeBackhoe {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeWrongMode,
+					}
+					break
+				}
+				p := reactor().Forward(armlink.JointPosition(*controller.CurrentRobotPose))
+				hmc <- api.HandlerMessage{
+					Type: api.TypeCurrentPose,
+					Value: []interface{}{api.CartesianState{
+						X:     p.X,
+						Y:     p.Y,
+						Z:     p.Z,
+						Pitch: p.Pitch * 180 / math.Pi,
+					}},
+				}
+			case api.TypeGetTelemetry:
+				// check the transport reads the servos
+				telemeter, ok := controller.Transport.(armlink.Telemeter)
 				if !ok {
 					hmc <- api.HandlerMessage{
-						Type: api.TypeSomethingWentWrong,
+						Type: api.TypeNotSupported,
 					}
 					break
 				}
-				// check if the token is valid
-				if robotCommand.Token != controller.CurrentUser.Token && robotCommand.Token != *mastertoken {
+				if !controller.Connected() {
 					hmc <- api.HandlerMessage{
-						Type: api.TypeInvalidToken,
+						Type: api.TypeRobotDisconnected,
 					}
 					break
 				}
-				// ack the timer
-				if *userTimeout != 0 {
-					controller.UserActChannel <- true
+				states, err := telemeter.Telemetry()
+				if err == armlink.ErrNoTelemetry {
+					hmc <- api.HandlerMessage{
+						Type: api.TypeNotSupported,
+					}
+					break
+				} else if err != nil {
+					hmc <- api.HandlerMessage{
+						Type:  api.TypeArmNotResponding,
+						Value: []interface{}{err.Error()},
+					}
+					break
 				}
-				// perform the reset
-				alp := &armlink.ArmLinkPacket{}
-				alp.SetExtended(armlink.ExtendedReset)
-				controller.ArmLinkSerial.Send(alp.Bytes())
-				// reset CurrentRobotPose
-				controller.ResetPose()
-				// sync with Leubot
-				alp = controller.CurrentRobotPose.BuildArmLinkPacket()
-				controller.ArmLinkSerial.Send(alp.Bytes())
-				log.Printf("[ArmLinkPacket] %v", alp.String())
-
 				hmc <- api.HandlerMessage{
-					Type: api.TypeActionPerformed,
+					Type:  api.TypeTelemetry,
+					Value: []interface{}{states},
 				}
 			}
 		}
@@ -574,6 +2630,16 @@ func postToSlack(msg string) {
 	}
 }
 
+// postTextToSlack posts the text to Slack if slackappenabled
+func postTextToSlack(text string) {
+	js, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		log.Printf("[Slack] %v", err)
+		return
+	}
+	postToSlack(string(js))
+}
+
 // switchLight turns on/off the light if miioenabled
 func switchLight(on bool) {
 	if *miioenabled {
@@ -590,13 +2656,78 @@ func main() {
 	app.Version(version)
 	parse := kingpin.MustParse(app.Parse(os.Args[1:]))
 	_ = parse
+	if *limitsfile != "" {
+		limits, err := armlink.LoadLimits(*limitsfile)
+		if err != nil {
+			app.Fatalf("%v", err)
+		}
+		armlink.SetJointLimits(limits)
+	}
+	if *calibrationfile != "" {
+		c, err := units.LoadCalibration(*calibrationfile)
+		if err != nil {
+			app.Fatalf("%v", err)
+		}
+		calibration = c
+	}
+	store, err := poses.Open(*posesfile)
+	if err != nil {
+		app.Fatalf("%v", err)
+	}
+	poseStore = store
+	recordingStore, err = recordings.Open(*recordingsdir)
+	if err != nil {
+		app.Fatalf("%v", err)
+	}
+	if *safetyfile != "" {
+		envelope, err = safety.Load(*safetyfile)
+		if err != nil {
+			app.Fatalf("%v", err)
+		}
+		// the arm goes home on every reset, mode change and new user, without a frame to check
+		if err := envelope.CheckPose(homePosition(), reactor()); err != nil {
+			app.Fatalf("the home pose is out of the safety envelope: %v", err)
+		}
+	}
+	if *programtimeout <= 0 {
+		app.Fatalf("the program timeout %v is not positive", *programtimeout)
+	}
+	if *trajectoryperiod < 16 {
+		app.Fatalf("the trajectory period %v is shorter than an ArmLink frame of 16 ms", *trajectoryperiod)
+	}
+	for _, speed := range []int{*defaultspeed, *maxspeed} {
+		if !armlink.SpeedLimit.Contains(speed) {
+			app.Fatalf("the speed %v is out of the range %v", speed, armlink.SpeedLimit)
+		}
+	}
 
-	// initialize ArmLink serial interface to control the robot
-	als := armlink.NewArmLinkSerial()
-	defer als.Close()
+	// initialize the transport to control the robot
+	if *simulator {
+		if *serial.Device != "serial://" && *serial.Device != "sim://" {
+			app.Fatalf("--simulator conflicts with --device %v", *serial.Device)
+		}
+		*serial.Device = "sim://"
+	}
+	transport, err := serial.Open()
+	if err != nil {
+		log.Fatalf("[Transport] %v", err)
+	}
+	log.Printf("[Transport] Opened %v", *serial.Device)
+	if *capture != "" {
+		f, err := os.Create(*capture)
+		if err != nil {
+			log.Fatalf("[Transport] %v", err)
+		}
+		transport, err = armlink.NewRecorder(transport, f)
+		if err != nil {
+			log.Fatalf("[Transport] %v", err)
+		}
+		log.Printf("[Transport] Recording to %v", *capture)
+	}
+	defer transport.Close()
 
-	// create the controller with the serial
-	controller := NewController(als)
+	// create the controller with the transport
+	controller := NewController(transport)
 	defer controller.Shutdown()
 
 	log.Printf("Server started")
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Interactions-HSG/leubot/api"
+	"github.com/Interactions-HSG/leubot/armlink"
+)
+
+// fakeTransport takes the frames sent, or fails them while failing
+type fakeTransport struct {
+	failing bool
+}
+
+func (ft *fakeTransport) Send(b []byte) error {
+	if ft.failing {
+		return errors.New("unplugged")
+	}
+	return nil
+}
+
+func (ft *fakeTransport) Receive(b []byte) (int, error) {
+	select {}
+}
+
+func (ft *fakeTransport) Close() error {
+	return nil
+}
+
+// newTestController returns a Controller at home in Joint mode, sending to the fakeTransport
+func newTestController() (*Controller, *fakeTransport) {
+	ft := &fakeTransport{}
+	pose := RobotPose(armlink.HomePosition())
+	position := RobotPosition{}
+	return &Controller{
+		Transport:            ft,
+		CurrentMode:          armlink.ModeBackhoe,
+		CurrentRobotPose:     &pose,
+		CurrentRobotPosition: &position,
+		CurrentUser:          &api.User{},
+		commands:             map[string]*commandRun{},
+	}, ft
+}
+
+// step is an action on the controller in a test of the command statuses
+type step func(t *testing.T, controller *Controller, ft *fakeTransport)
+
+// move performs a move of the base to the value, queued or not
+func move(base uint16, queue bool) step {
+	return func(t *testing.T, controller *Controller, ft *fakeTransport) {
+		pose := *controller.CurrentRobotPose
+		pose.Base = base
+		alp, err := pose.BuildArmLinkPacket(byte(armlink.DeltaLimit.Default))
+		if err != nil {
+			t.Fatal(err)
+		}
+		controller.perform(alp, "token", queue, &pose, nil)
+	}
+}
+
+// complete completes the command executing, as the timer of the command does
+func complete(t *testing.T, controller *Controller, ft *fakeTransport) {
+	controller.stepCommand()
+}
+
+// cancel cancels the command in line at the index, as DELETE does
+func cancel(i int) step {
+	return func(t *testing.T, controller *Controller, ft *fakeTransport) {
+		controller.cancelCommands(i, nil)
+	}
+}
+
+// stop cancels the commands as the emergency stop does
+func stop(t *testing.T, controller *Controller, ft *fakeTransport) {
+	controller.cancelCommands(0, errors.New("stopped"))
+}
+
+// reset sends a reset to the arm, which is not a motion command
+func reset(t *testing.T, controller *Controller, ft *fakeTransport) {
+	alp := &armlink.ArmLinkPacket{}
+	alp.SetExtended(armlink.ExtendedReset)
+	if err := controller.send(alp); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// unplug makes the sends fail
+func unplug(t *testing.T, controller *Controller, ft *fakeTransport) {
+	ft.failing = true
+}
+
+// want is the status and the error of a command
+type want struct {
+	status string
+	err    string
+}
+
+func TestCommandStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []step
+		want  []want
+		// the base commanded once done, where the arm is left
+		base uint16
+	}{
+		{
+			"completed",
+			[]step{move(100, false), complete},
+			[]want{{api.CommandCompleted, ""}},
+			100,
+		},
+		{
+			"queued then completed in order",
+			[]step{move(100, false), move(200, true), move(300, true), complete, complete, complete},
+			[]want{{api.CommandCompleted, ""}, {api.CommandCompleted, ""}, {api.CommandCompleted, ""}},
+			300,
+		},
+		{
+			"the next one executing once the first completes",
+			[]step{move(100, false), move(200, true), move(300, true), complete},
+			[]want{{api.CommandCompleted, ""}, {api.CommandExecuting, ""}, {api.CommandQueued, ""}},
+			300,
+		},
+		{
+			"superseded by a move not queued",
+			[]step{move(100, false), move(200, false)},
+			[]want{{api.CommandFailed, "superseded by command 2"}, {api.CommandExecuting, ""}},
+			200,
+		},
+		{
+			"superseded by another move",
+			[]step{move(100, false), reset},
+			[]want{{api.CommandFailed, "superseded by another move"}},
+			100,
+		},
+		{
+			"the queued ones canceled by DELETE",
+			[]step{move(100, false), move(200, true), move(300, true), cancel(1)},
+			[]want{{api.CommandExecuting, ""}, {api.CommandCanceled, ""}, {api.CommandCanceled, ""}},
+			100,
+		},
+		{
+			"the last one canceled by DELETE",
+			[]step{move(100, false), move(200, true), move(300, true), cancel(2), complete, complete},
+			[]want{{api.CommandCompleted, ""}, {api.CommandCompleted, ""}, {api.CommandCanceled, ""}},
+			200,
+		},
+		{
+			"stopped on the way",
+			[]step{move(100, false), move(200, true), stop},
+			[]want{{api.CommandFailed, "stopped"}, {api.CommandCanceled, ""}},
+			100,
+		},
+		{
+			"failed to be sent",
+			[]step{unplug, move(100, false)},
+			[]want{{api.CommandFailed, "unplugged"}},
+			512,
+		},
+		{
+			"failed to be sent after the first",
+			[]step{move(100, false), move(200, true), move(300, true), unplug, complete},
+			[]want{{api.CommandCompleted, ""}, {api.CommandFailed, "unplugged"}, {api.CommandCanceled, ""}},
+			100,
+		},
+	}
+	for _, tt := range tests {
+		controller, ft := newTestController()
+		for _, s := range tt.steps {
+			s(t, controller, ft)
+		}
+		for i, w := range tt.want {
+			c, ok := controller.commands[fmt.Sprint(i+1)]
+			if !ok {
+				t.Errorf("%v: command %v not found", tt.name, i+1)
+				continue
+			}
+			cs := controller.CommandState(c)
+			if cs.Status != w.status || cs.Error != w.err {
+				t.Errorf("%v: command %v is %v %q, want %v %q", tt.name, c.id, cs.Status, cs.Error, w.status, w.err)
+			}
+			if done := cs.Status != api.CommandQueued && cs.Status != api.CommandExecuting; done != (cs.Finished != nil) {
+				t.Errorf("%v: command %v is %v with the finish time %v", tt.name, c.id, cs.Status, cs.Finished)
+			}
+		}
+		if controller.CurrentRobotPose.Base != tt.base {
+			t.Errorf("%v: the base is commanded at %v, want %v", tt.name, controller.CurrentRobotPose.Base, tt.base)
+		}
+	}
+}
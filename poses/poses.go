@@ -0,0 +1,197 @@
+// Package poses stores the named poses of the arm in a JSON file,
+// shared by leubot and reactor-ctrl.
+package poses
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+)
+
+// ErrReadOnly is returned when a user saves over a pose defined by the admins
+var ErrReadOnly = errors.New("poses: the pose is read-only")
+
+// validName are the names of the poses, usable in a URL
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Pose is a named position of the joints, ReadOnly for the poses defined by the admins
+type Pose struct {
+	Name          string `json:"name"`
+	Base          uint16 `json:"base"`
+	Shoulder      uint16 `json:"shoulder"`
+	Elbow         uint16 `json:"elbow"`
+	WristAngle    uint16 `json:"wristAngle"`
+	WristRotation uint16 `json:"wristRotation"`
+	Gripper       uint16 `json:"gripper"`
+	ReadOnly      bool   `json:"readOnly,omitempty"`
+}
+
+// NewPose creates a new Pose of the JointPosition
+func NewPose(name string, jp armlink.JointPosition, readOnly bool) Pose {
+	return Pose{
+		Name:          name,
+		Base:          jp.Base,
+		Shoulder:      jp.Shoulder,
+		Elbow:         jp.Elbow,
+		WristAngle:    jp.WristAngle,
+		WristRotation: jp.WristRotation,
+		Gripper:       jp.Gripper,
+		ReadOnly:      readOnly,
+	}
+}
+
+// JointPosition returns the position of the joints of the Pose
+func (p Pose) JointPosition() armlink.JointPosition {
+	return armlink.JointPosition{
+		Base:          p.Base,
+		Shoulder:      p.Shoulder,
+		Elbow:         p.Elbow,
+		WristAngle:    p.WristAngle,
+		WristRotation: p.WristRotation,
+		Gripper:       p.Gripper,
+	}
+}
+
+// Defaults are the read-only poses available unless the file overrides them
+func Defaults() []Pose {
+	return []Pose{
+		NewPose("home", armlink.HomePosition(), true),
+		NewPose("rest", armlink.RestPosition(), true),
+	}
+}
+
+// Store is the named poses saved in a JSON file
+type Store struct {
+	path  string
+	mutex sync.Mutex
+	poses map[string]Pose
+	// saved are the names of the poses in the file, the Defaults not overridden are left out
+	// so they follow the joint limits of the arm
+	saved map[string]bool
+}
+
+// Open reads the Store from the file at the path, with only the Defaults if it does not exist yet,
+// or returns an error if a pose of the file is out of the joint limits
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:  path,
+		poses: map[string]Pose{},
+		saved: map[string]bool{},
+	}
+	for _, p := range Defaults() {
+		s.poses[p.Name] = p
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var poses []Pose
+		if err := json.Unmarshal(b, &poses); err != nil {
+			return nil, fmt.Errorf("poses: invalid file %v: %v", path, err)
+		}
+		for _, p := range poses {
+			if err := p.JointPosition().Validate(); err != nil {
+				return nil, fmt.Errorf("poses: invalid pose %q in %v: %v", p.Name, path, err)
+			}
+			s.poses[p.Name] = p
+			s.saved[p.Name] = true
+		}
+	}
+	return s, nil
+}
+
+// Get returns the Pose by its name
+func (s *Store) Get(name string) (Pose, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p, ok := s.poses[name]
+	return p, ok
+}
+
+// List returns the Poses sorted by their names
+func (s *Store) List() []Pose {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.list()
+}
+
+func (s *Store) list() []Pose {
+	list := []Pose{}
+	for _, p := range s.poses {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// CheckName returns an error if the name is not valid for a Pose
+func CheckName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("poses: invalid name %q, up to 32 letters, digits, - or _", name)
+	}
+	return nil
+}
+
+// Save saves the Pose to the file, over a read-only one only by an admin
+func (s *Store) Save(p Pose, admin bool) error {
+	if err := CheckName(p.Name); err != nil {
+		return err
+	}
+	if err := p.JointPosition().Validate(); err != nil {
+		return err
+	}
+	if p.ReadOnly && !admin {
+		return ErrReadOnly
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if old, ok := s.poses[p.Name]; ok && old.ReadOnly && !admin {
+		return ErrReadOnly
+	}
+	old, existed := s.poses[p.Name]
+	wasSaved := s.saved[p.Name]
+	s.poses[p.Name] = p
+	s.saved[p.Name] = true
+	if err := s.write(); err != nil {
+		// keep the Store as the file is
+		if existed {
+			s.poses[p.Name] = old
+		} else {
+			delete(s.poses, p.Name)
+		}
+		if !wasSaved {
+			delete(s.saved, p.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// write writes the saved poses to the file, through a temporary one not to lose it halfway
+func (s *Store) write() error {
+	list := []Pose{}
+	for _, p := range s.list() {
+		if s.saved[p.Name] {
+			list = append(list, p)
+		}
+	}
+	b, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,203 @@
+package poses
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+)
+
+// tempDir creates a directory for the test, to be removed
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "poses")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// readFile returns the names of the poses written to the file at the path
+func readFile(t *testing.T, path string) []string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var poses []Pose
+	if err := json.Unmarshal(b, &poses); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, p := range poses {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestOpen(t *testing.T) {
+	home := NewPose("home", armlink.HomePosition(), true)
+	home.Elbow = 500
+	tests := []struct {
+		name    string
+		file    string
+		want    []string
+		home    Pose
+		wantErr bool
+	}{
+		{"no file", "", []string{"home", "rest"}, Defaults()[0], false},
+		{"a pose saved", `[{"name": "up", "base": 512, "shoulder": 512, "elbow": 400, "wristAngle": 580, "wristRotation": 512, "gripper": 128}]`, []string{"home", "rest", "up"}, Defaults()[0], false},
+		{"home saved over", `[{"name": "home", "base": 512, "shoulder": 400, "elbow": 500, "wristAngle": 580, "wristRotation": 512, "gripper": 128, "readOnly": true}]`, []string{"home", "rest"}, home, false},
+		{"out of the joint limits", `[{"name": "low", "base": 512, "shoulder": 100, "elbow": 400, "wristAngle": 580, "wristRotation": 512, "gripper": 128}]`, nil, Pose{}, true},
+		{"not JSON", `{`, nil, Pose{}, true},
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	for i, tt := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("poses%d.json", i))
+		if tt.file != "" {
+			if err := ioutil.WriteFile(path, []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s, err := Open(path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: Open() error = %v, want an error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		names := []string{}
+		for _, p := range s.List() {
+			names = append(names, p.Name)
+		}
+		if len(names) != len(tt.want) {
+			t.Errorf("%v: List() = %v, want %v", tt.name, names, tt.want)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.want[i] {
+				t.Errorf("%v: List() = %v, want %v", tt.name, names, tt.want)
+				break
+			}
+		}
+		if p, _ := s.Get("home"); p != tt.home {
+			t.Errorf("%v: Get(home) = %+v, want %+v", tt.name, p, tt.home)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	up := NewPose("up", armlink.HomePosition(), false)
+	up.Shoulder = 512
+	low := up
+	low.Shoulder = 100
+	home := NewPose("home", armlink.HomePosition(), false)
+	locked := NewPose("locked", armlink.HomePosition(), true)
+	tests := []struct {
+		name    string
+		pose    Pose
+		admin   bool
+		wantErr error
+		written []string
+	}{
+		{"a user pose", up, false, nil, []string{"up"}},
+		{"a read-only pose by an admin", locked, true, nil, []string{"locked"}},
+		{"a read-only pose by a user", locked, false, ErrReadOnly, nil},
+		{"over a default by a user", home, false, ErrReadOnly, nil},
+		{"over a default by an admin", home, true, nil, []string{"home"}},
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	for i, tt := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("poses%d.json", i))
+		s, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Save(tt.pose, tt.admin); err != tt.wantErr {
+			t.Errorf("%v: Save() error = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr != nil {
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("%v: the file was written", tt.name)
+			}
+			continue
+		}
+		// only the poses saved are written, the defaults follow the joint limits
+		written := readFile(t, path)
+		if len(written) != len(tt.written) || written[0] != tt.written[0] {
+			t.Errorf("%v: written %v, want %v", tt.name, written, tt.written)
+		}
+		if p, _ := s.Get(tt.pose.Name); p != tt.pose {
+			t.Errorf("%v: Get() = %+v, want %+v", tt.name, p, tt.pose)
+		}
+	}
+
+	// the invalid poses are not saved
+	s, err := Open(filepath.Join(dir, "invalid.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(low, false); err == nil {
+		t.Errorf("Save() of a pose out of the joint limits = nil, want an error")
+	}
+	if err := s.Save(NewPose("a/b", armlink.HomePosition(), false), false); err == nil {
+		t.Errorf("Save() of an invalid name = nil, want an error")
+	}
+}
+
+func TestSaveRollback(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "poses.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	up := NewPose("up", armlink.HomePosition(), false)
+	if err := s.Save(up, false); err != nil {
+		t.Fatal(err)
+	}
+	// the file can not be written anymore
+	if err := os.Mkdir(path+".tmp", 0755); err != nil {
+		t.Fatal(err)
+	}
+	moved := up
+	moved.Base = 600
+	home := NewPose("home", armlink.HomePosition(), true)
+	home.Base = 600
+	tests := []struct {
+		name string
+		pose Pose
+		want Pose
+		ok   bool
+	}{
+		{"over a saved pose", moved, up, true},
+		{"a new pose", NewPose("new", armlink.HomePosition(), false), Pose{}, false},
+		{"over a default", home, Defaults()[0], true},
+	}
+	for _, tt := range tests {
+		if err := s.Save(tt.pose, true); err == nil {
+			t.Errorf("%v: Save() = nil, want an error", tt.name)
+			continue
+		}
+		if p, ok := s.Get(tt.pose.Name); p != tt.want || ok != tt.ok {
+			t.Errorf("%v: Get() = %+v, %v after the failed Save(), want %+v, %v", tt.name, p, ok, tt.want, tt.ok)
+		}
+	}
+	// nor are the failed ones written with the next pose saved
+	if err := os.Remove(path + ".tmp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(NewPose("next", armlink.HomePosition(), false), false); err != nil {
+		t.Fatal(err)
+	}
+	if written := readFile(t, path); len(written) != 2 || written[0] != "next" || written[1] != "up" {
+		t.Errorf("written %v, want [next up]", written)
+	}
+}
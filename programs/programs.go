@@ -0,0 +1,415 @@
+// Package programs runs the motion programs of the users in an embedded Starlark interpreter.
+// The programs have no access to the files or the network, are bounded in steps and in time,
+// and move the arm through api.HandlerChannel with the token of their owner, as the requests of the API do.
+package programs
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Interactions-HSG/leubot/api"
+	"github.com/Interactions-HSG/leubot/armlink"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// MaxSource is the largest source of a program in bytes
+const MaxSource = 64 * 1024
+
+// maxOutput is the number of the last printed lines kept
+const maxOutput = 100
+
+// fileOptions allow the loops and the conditionals at the top level of a program
+var fileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+}
+
+// Limits bound a Run, Steps in Starlark computation steps and Timeout in wall-clock time including the waits
+type Limits struct {
+	Steps   uint64
+	Timeout time.Duration
+}
+
+// Run is a program compiled for its Owner token
+type Run struct {
+	ID       string
+	Owner    string
+	program  *starlark.Program
+	thread   *starlark.Thread
+	canceled chan struct{}
+	once     sync.Once
+	mutex    sync.Mutex
+	status   string
+	output   []string
+	err      string
+	started  time.Time
+	elapsed  time.Duration
+	steps    uint64
+}
+
+// Compile checks the source and returns a Run of it by the owner, or an error if it is invalid
+func Compile(id, owner, source string) (*Run, error) {
+	if len(source) > MaxSource {
+		return nil, fmt.Errorf("programs: the source is larger than %v bytes", MaxSource)
+	}
+	r := &Run{
+		ID:       id,
+		Owner:    owner,
+		canceled: make(chan struct{}),
+	}
+	_, program, err := starlark.SourceProgramOptions(fileOptions, "program-"+id+".star", source, r.builtins().Has)
+	if err != nil {
+		return nil, err
+	}
+	r.program = program
+	return r, nil
+}
+
+// Start runs the program in its own goroutine until it ends, fails or is canceled
+func (r *Run) Start(limits Limits) {
+	r.thread = &starlark.Thread{
+		Name:  "program " + r.ID,
+		Print: r.print,
+		// no load() in the sandbox
+		Load: func(*starlark.Thread, string) (starlark.StringDict, error) {
+			return nil, errors.New("load is not available in the programs")
+		},
+	}
+	r.thread.SetMaxExecutionSteps(limits.Steps)
+	r.status = api.ProgramRunning
+	r.started = time.Now()
+	timeout := time.AfterFunc(limits.Timeout, func() {
+		r.end(api.ProgramFailed, fmt.Sprintf("the program ran over %v", limits.Timeout))
+	})
+	go func() {
+		_, err := r.program.Init(r.thread, r.builtins())
+		timeout.Stop()
+		r.mutex.Lock()
+		r.steps = r.thread.ExecutionSteps()
+		r.mutex.Unlock()
+		if err != nil {
+			if evalErr, ok := err.(*starlark.EvalError); ok {
+				r.end(api.ProgramFailed, evalErr.Backtrace())
+				return
+			}
+			r.end(api.ProgramFailed, err.Error())
+			return
+		}
+		r.end(api.ProgramDone, "")
+	}()
+}
+
+// Cancel stops the program with the status, canceled or stopped
+func (r *Run) Cancel(status string) {
+	r.end(status, "")
+}
+
+// end sets the status of the program if still running, and interrupts it
+func (r *Run) end(status, reason string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.status != api.ProgramRunning {
+		return
+	}
+	r.status = status
+	r.err = reason
+	r.elapsed = time.Since(r.started)
+	r.once.Do(func() {
+		close(r.canceled)
+		r.thread.Cancel(status)
+	})
+}
+
+// Running reports if the program is still running
+func (r *Run) Running() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.status == api.ProgramRunning
+}
+
+// State returns the progress of the program
+func (r *Run) State() api.ProgramState {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	elapsed := r.elapsed
+	if r.status == api.ProgramRunning {
+		elapsed = time.Since(r.started)
+	}
+	return api.ProgramState{
+		ID:      r.ID,
+		Status:  r.status,
+		Output:  append([]string{}, r.output...),
+		Error:   r.err,
+		Elapsed: int(elapsed / time.Millisecond),
+		Steps:   r.steps,
+	}
+}
+
+// print keeps the printed line, dropping the oldest ones beyond maxOutput
+func (r *Run) print(_ *starlark.Thread, msg string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.output = append(r.output, msg)
+	if len(r.output) > maxOutput {
+		r.output = r.output[len(r.output)-maxOutput:]
+	}
+}
+
+// snakeCase are the names of the joints in the programs, as the arguments of move()
+var snakeCase = map[string]string{
+	"wristAngle":    "wrist_angle",
+	"wristRotation": "wrist_rotation",
+}
+
+// builtins are the functions available to the programs
+func (r *Run) builtins() starlark.StringDict {
+	return starlark.StringDict{
+		"move":          starlark.NewBuiltin("move", r.move),
+		"go":            starlark.NewBuiltin("go", r.goTo),
+		"open_gripper":  starlark.NewBuiltin("open_gripper", r.gripper(armlink.GripperLimit.Max)),
+		"close_gripper": starlark.NewBuiltin("close_gripper", r.gripper(armlink.GripperLimit.Min)),
+		"wait":          starlark.NewBuiltin("wait", r.wait),
+		"pose":          starlark.NewBuiltin("pose", r.pose),
+	}
+}
+
+// command sends the msg to the controller as a request of the API would,
+// and returns an error unless the action is performed or if the program is canceled
+func (r *Run) command(msg api.HandlerMessage) error {
+	msg.Program = r.ID
+	// a canceled program sends nothing more, the controller rejects what it sent meanwhile
+	select {
+	case <-r.canceled:
+		return errors.New("canceled")
+	default:
+	}
+	select {
+	case api.HandlerChannel <- msg:
+	case <-r.canceled:
+		return errors.New("canceled")
+	}
+	reply, ok := <-api.HandlerChannel
+	if !ok {
+		return errors.New("the controller is gone")
+	}
+	switch reply.Type {
+	case api.TypeActionPerformed:
+		return nil
+	case api.TypeInvalidCommand:
+		if s, ok := reason(reply); ok {
+			return fmt.Errorf("invalid command: %v", s)
+		}
+		return errors.New("invalid command")
+	case api.TypeInvalidToken:
+		return errors.New("invalid token, the user has left")
+	case api.TypeWrongMode:
+		return errors.New("not in Joint mode")
+	case api.TypePoseNotFound:
+		return errors.New("no such pose")
+	case api.TypeUnsafePose:
+		if s, ok := reason(reply); ok {
+			return errors.New(s)
+		}
+		return errors.New("the gripper would leave the safety envelope")
+	case api.TypeCommandsQueued:
+		if s, ok := reason(reply); ok {
+			return errors.New(s)
+		}
+		return errors.New("motion commands are queued")
+	case api.TypeStopped:
+		return errors.New("the robot is stopped until re-armed")
+	case api.TypeRobotDisconnected:
+		return errors.New("the robot is disconnected")
+	case api.TypeProgramFinished:
+		return errors.New("canceled")
+	}
+	return errors.New("something went wrong")
+}
+
+// reason returns the message the controller replied the reply with, if any
+func reason(reply api.HandlerMessage) (string, bool) {
+	if len(reply.Value) > 0 {
+		s, ok := reply.Value[0].(string)
+		return s, ok
+	}
+	return "", false
+}
+
+// optionalFloat returns the number v, nil if omitted or None
+func optionalFloat(name string, v starlark.Value) (*float64, error) {
+	if v == nil || v == starlark.None {
+		return nil, nil
+	}
+	f, ok := starlark.AsFloat(v)
+	if !ok {
+		return nil, fmt.Errorf("%v: got %v, want a number", name, v.Type())
+	}
+	return &f, nil
+}
+
+// optionalInt returns the integer v, nil if omitted or None
+func optionalInt(name string, v starlark.Value) (*int, error) {
+	if v == nil || v == starlark.None {
+		return nil, nil
+	}
+	i, err := starlark.AsInt32(v)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	return &i, nil
+}
+
+// move(base=None, shoulder=None, elbow=None, wrist_angle=None, wrist_rotation=None, gripper=None, unit="", speed=None)
+// moves the given joints at once, as PUT /pose
+func (r *Run) move(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var base, shoulder, elbow, wristAngle, wristRotation, gripper, speed starlark.Value
+	var unit string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs,
+		"base?", &base,
+		"shoulder?", &shoulder,
+		"elbow?", &elbow,
+		"wrist_angle?", &wristAngle,
+		"wrist_rotation?", &wristRotation,
+		"gripper?", &gripper,
+		"unit?", &unit,
+		"speed?", &speed,
+	); err != nil {
+		return nil, err
+	}
+	poseCommand := api.PoseCommand{
+		Token: r.Owner,
+		Unit:  unit,
+	}
+	var err error
+	for _, jv := range []struct {
+		name  string
+		value starlark.Value
+		field **float64
+	}{
+		{"base", base, &poseCommand.Base},
+		{"shoulder", shoulder, &poseCommand.Shoulder},
+		{"elbow", elbow, &poseCommand.Elbow},
+		{"wrist_angle", wristAngle, &poseCommand.WristAngle},
+		{"wrist_rotation", wristRotation, &poseCommand.WristRotation},
+		{"gripper", gripper, &poseCommand.Gripper},
+	} {
+		if *jv.field, err = optionalFloat(jv.name, jv.value); err != nil {
+			return nil, err
+		}
+	}
+	if poseCommand.Speed, err = optionalInt("speed", speed); err != nil {
+		return nil, err
+	}
+	if err := r.command(api.HandlerMessage{
+		Type:  api.TypePutPose,
+		Value: []interface{}{poseCommand},
+	}); err != nil {
+		return nil, err
+	}
+	return starlark.None, nil
+}
+
+// go(name, speed=None) moves to the named pose, as POST /poses/{name}/go
+func (r *Run) goTo(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var speed starlark.Value
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "speed?", &speed); err != nil {
+		return nil, err
+	}
+	goCommand := api.GoCommand{
+		Token: r.Owner,
+	}
+	var err error
+	if goCommand.Speed, err = optionalInt("speed", speed); err != nil {
+		return nil, err
+	}
+	if err := r.command(api.HandlerMessage{
+		Type:  api.TypePostNamedPose,
+		Value: []interface{}{name, goCommand},
+	}); err != nil {
+		return nil, fmt.Errorf("%q: %v", name, err)
+	}
+	return starlark.None, nil
+}
+
+// gripper returns the builtin moving the gripper to the value in ticks with an optional speed
+func (r *Run) gripper(value int) func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error) {
+	return func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var speed starlark.Value
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "speed?", &speed); err != nil {
+			return nil, err
+		}
+		gripper := float64(value)
+		poseCommand := api.PoseCommand{
+			Token:   r.Owner,
+			Gripper: &gripper,
+		}
+		var err error
+		if poseCommand.Speed, err = optionalInt("speed", speed); err != nil {
+			return nil, err
+		}
+		if err := r.command(api.HandlerMessage{
+			Type:  api.TypePutPose,
+			Value: []interface{}{poseCommand},
+		}); err != nil {
+			return nil, err
+		}
+		return starlark.None, nil
+	}
+}
+
+// wait(seconds) sleeps, until the program is canceled
+func (r *Run) wait(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var seconds starlark.Value
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "seconds", &seconds); err != nil {
+		return nil, err
+	}
+	s, ok := starlark.AsFloat(seconds)
+	if !ok || s < 0 {
+		return nil, fmt.Errorf("got %v, want a positive number of seconds", seconds)
+	}
+	select {
+	case <-time.After(time.Duration(s * float64(time.Second))):
+		return starlark.None, nil
+	case <-r.canceled:
+		return nil, errors.New("canceled")
+	}
+}
+
+// pose() returns the state of the arm as GET /pose, with the joints in ticks, degrees and radians
+func (r *Run) pose(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+	api.HandlerChannel <- api.HandlerMessage{
+		Type: api.TypeGetPose,
+	}
+	reply, ok := <-api.HandlerChannel
+	if !ok || reply.Type != api.TypeCurrentPose || len(reply.Value) == 0 {
+		return nil, errors.New("something went wrong")
+	}
+	poseState, ok := reply.Value[0].(api.PoseState)
+	if !ok {
+		return nil, errors.New("something went wrong")
+	}
+	d := starlark.NewDict(len(poseState.Joints) + 3)
+	d.SetKey(starlark.String("mode"), starlark.String(poseState.Mode))
+	d.SetKey(starlark.String("asleep"), starlark.Bool(poseState.Asleep))
+	d.SetKey(starlark.String("stopped"), starlark.Bool(poseState.Stopped))
+	for name, js := range poseState.Joints {
+		if n, ok := snakeCase[name]; ok {
+			name = n
+		}
+		joint := starlark.NewDict(3)
+		joint.SetKey(starlark.String("value"), starlark.MakeInt(js.Value))
+		joint.SetKey(starlark.String("degrees"), starlark.Float(js.Degrees))
+		joint.SetKey(starlark.String("radians"), starlark.Float(js.Radians))
+		d.SetKey(starlark.String(name), joint)
+	}
+	return d, nil
+}
@@ -0,0 +1,172 @@
+package programs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Interactions-HSG/leubot/api"
+)
+
+// limits are large enough for the programs that end by themselves
+var limits = Limits{Steps: 100000, Timeout: 2 * time.Second}
+
+// waitEnd waits for the run to end, or fails the test
+func waitEnd(t *testing.T, r *Run) api.ProgramState {
+	deadline := time.Now().Add(5 * time.Second)
+	for r.Running() {
+		if time.Now().After(deadline) {
+			t.Fatalf("program %v still running", r.ID)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return r.State()
+}
+
+// fakeController replies to the commands sent on api.HandlerChannel with the reply type,
+// and passes the commands on to sent
+func fakeController(reply api.HandlerMessageType) (sent chan api.HandlerMessage) {
+	api.HandlerChannel = make(chan api.HandlerMessage)
+	sent = make(chan api.HandlerMessage, 10)
+	go func(hmc chan api.HandlerMessage) {
+		for msg := range hmc {
+			sent <- msg
+			hmc <- api.HandlerMessage{Type: reply}
+		}
+	}(api.HandlerChannel)
+	return sent
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr bool
+	}{
+		{"a loop at the top level", "for i in range(3):\n    move(base=i)\n", false},
+		{"a while loop", "i = 0\nwhile i < 3:\n    i += 1\n", false},
+		{"too large", strings.Repeat("#", MaxSource+1), true},
+		{"a syntax error", "move(", true},
+		{"an undefined name", "open('/etc/passwd')\n", true},
+	}
+	for _, tt := range tests {
+		_, err := Compile("1", "token", tt.source)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: Compile() error = %v, want an error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		limits Limits
+		// cancel the run once started with the status
+		cancel  string
+		status  string
+		err     string
+		outputs int
+	}{
+		{"done", "print('hello')\n", limits, "", api.ProgramDone, "", 1},
+		{"a runtime error", "x = 1 // 0\n", limits, "", api.ProgramFailed, "division by zero", 0},
+		{"no load", "load('lib.star', 'f')\n", limits, "", api.ProgramFailed, "load is not available", 0},
+		{"over the steps", "while True:\n    pass\n", Limits{Steps: 1000, Timeout: 2 * time.Second}, "", api.ProgramFailed, "too many steps", 0},
+		{"over the timeout", "wait(10)\n", Limits{Steps: 1000, Timeout: 50 * time.Millisecond}, "", api.ProgramFailed, "the program ran over 50ms", 0},
+		{"canceled while waiting", "wait(10)\n", limits, api.ProgramCanceled, api.ProgramCanceled, "", 0},
+		{"stopped while looping", "while True:\n    pass\n", Limits{Steps: 0, Timeout: 2 * time.Second}, api.ProgramStopped, api.ProgramStopped, "", 0},
+	}
+	for _, tt := range tests {
+		r, err := Compile("1", "token", tt.source)
+		if err != nil {
+			t.Errorf("%v: Compile() error = %v", tt.name, err)
+			continue
+		}
+		r.Start(tt.limits)
+		if tt.cancel != "" {
+			time.Sleep(10 * time.Millisecond)
+			r.Cancel(tt.cancel)
+		}
+		ps := waitEnd(t, r)
+		if ps.Status != tt.status || !strings.Contains(ps.Error, tt.err) || (tt.err == "") != (ps.Error == "") {
+			t.Errorf("%v: the program is %v %q, want %v %q", tt.name, ps.Status, ps.Error, tt.status, tt.err)
+		}
+		if len(ps.Output) != tt.outputs {
+			t.Errorf("%v: %v lines printed, want %v", tt.name, len(ps.Output), tt.outputs)
+		}
+	}
+}
+
+func TestRunOutput(t *testing.T) {
+	r, err := Compile("1", "token", "for i in range(150):\n    print(i)\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Start(limits)
+	// the oldest lines are dropped
+	ps := waitEnd(t, r)
+	if len(ps.Output) != maxOutput || ps.Output[0] != "50" || ps.Output[maxOutput-1] != "149" {
+		t.Errorf("Output = %v, want 50 to 149", ps.Output)
+	}
+}
+
+func TestRunCommands(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		reply  api.HandlerMessageType
+		status string
+		err    string
+		sent   int
+	}{
+		{"performed", "move(base=512, speed=50)\nclose_gripper()\ngo('home')\n", api.TypeActionPerformed, api.ProgramDone, "", 3},
+		{"the user has left", "move(base=512)\nmove(base=600)\n", api.TypeInvalidToken, api.ProgramFailed, "the user has left", 1},
+		{"rejected as canceled", "go('home')\n", api.TypeProgramFinished, api.ProgramFailed, "canceled", 1},
+		{"a speed not an integer", "move(base=512, speed=1.5)\n", api.TypeActionPerformed, api.ProgramFailed, "speed", 0},
+	}
+	for _, tt := range tests {
+		sent := fakeController(tt.reply)
+		r, err := Compile("7", "token", tt.source)
+		if err != nil {
+			t.Errorf("%v: Compile() error = %v", tt.name, err)
+			continue
+		}
+		r.Start(limits)
+		ps := waitEnd(t, r)
+		close(api.HandlerChannel)
+		if ps.Status != tt.status || !strings.Contains(ps.Error, tt.err) {
+			t.Errorf("%v: the program is %v %q, want %v %q", tt.name, ps.Status, ps.Error, tt.status, tt.err)
+		}
+		if len(sent) != tt.sent {
+			t.Errorf("%v: %v commands sent, want %v", tt.name, len(sent), tt.sent)
+		}
+		// the commands carry the program, rejected by the controller once it is canceled
+		for len(sent) > 0 {
+			msg := <-sent
+			if msg.Program != "7" {
+				t.Errorf("%v: %v sent by the program %q, want 7", tt.name, msg.Type, msg.Program)
+			}
+		}
+	}
+}
+
+func TestCanceledSendsNothing(t *testing.T) {
+	// no controller reads the commands
+	api.HandlerChannel = make(chan api.HandlerMessage)
+	r, err := Compile("1", "token", "move(base=512)\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Start(limits)
+	time.Sleep(10 * time.Millisecond)
+	r.Cancel(api.ProgramCanceled)
+	if ps := waitEnd(t, r); ps.Status != api.ProgramCanceled {
+		t.Errorf("the program is %v, want %v", ps.Status, api.ProgramCanceled)
+	}
+	// the program blocked on the send is done without sending
+	select {
+	case msg := <-api.HandlerChannel:
+		t.Errorf("%v sent after the cancel", msg.Type)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,303 @@
+// Package recordings stores the joint moves recorded in teach mode, one JSON file each,
+// and turns them into trajectories to replay them.
+package recordings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+	"github.com/Interactions-HSG/leubot/trajectory"
+)
+
+// The playback rates of a Recording, from half to twice the recorded speed
+const (
+	MinRate = 0.5
+	MaxRate = 2.0
+)
+
+// LeadIn is the time to move from where the arm is to the first frame before a replay
+const LeadIn = 2 * time.Second
+
+// frameDelta is the time the firmware takes to interpolate per unit of the delta byte
+const frameDelta = 16 * time.Millisecond
+
+// Frame is a pose of the joints sent At milliseconds since the start of the Recording,
+// moving there over Delta frames of the firmware
+type Frame struct {
+	At            int    `json:"at"`
+	Base          uint16 `json:"base"`
+	Shoulder      uint16 `json:"shoulder"`
+	Elbow         uint16 `json:"elbow"`
+	WristAngle    uint16 `json:"wristAngle"`
+	WristRotation uint16 `json:"wristRotation"`
+	Gripper       uint16 `json:"gripper"`
+	Delta         byte   `json:"delta"`
+}
+
+// JointPosition returns the position of the joints of the Frame
+func (f Frame) JointPosition() armlink.JointPosition {
+	return armlink.JointPosition{
+		Base:          f.Base,
+		Shoulder:      f.Shoulder,
+		Elbow:         f.Elbow,
+		WristAngle:    f.WristAngle,
+		WristRotation: f.WristRotation,
+		Gripper:       f.Gripper,
+	}
+}
+
+// Info describes a Recording without its frames, Duration is in milliseconds
+// and Active while it is being recorded
+type Info struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	User     string    `json:"user"`
+	Created  time.Time `json:"created"`
+	Duration int       `json:"duration"`
+	Count    int       `json:"count"`
+	Active   bool      `json:"active,omitempty"`
+}
+
+// Recording is the sequence of the poses sent to the arm
+type Recording struct {
+	Info
+	Frames []Frame `json:"frames"`
+}
+
+// New starts a new Recording by the user from the current position of the joints
+func New(id, name, user string, jp armlink.JointPosition) *Recording {
+	r := &Recording{
+		Info: Info{
+			ID:      id,
+			Name:    name,
+			User:    user,
+			Created: time.Now(),
+			Active:  true,
+		},
+	}
+	r.Add(0, jp, 0)
+	return r
+}
+
+// Add appends the pose sent the elapsed time since the start, unless it is the same as the last one
+func (r *Recording) Add(elapsed time.Duration, jp armlink.JointPosition, delta byte) {
+	if n := len(r.Frames); n > 0 && r.Frames[n-1].JointPosition() == jp {
+		return
+	}
+	r.Frames = append(r.Frames, Frame{
+		At:            int(elapsed / time.Millisecond),
+		Base:          jp.Base,
+		Shoulder:      jp.Shoulder,
+		Elbow:         jp.Elbow,
+		WristAngle:    jp.WristAngle,
+		WristRotation: jp.WristRotation,
+		Gripper:       jp.Gripper,
+		Delta:         delta,
+	})
+	r.Count = len(r.Frames)
+}
+
+// Finish ends the Recording the elapsed time since the start
+func (r *Recording) Finish(elapsed time.Duration) {
+	r.Duration = int(elapsed / time.Millisecond)
+	r.Active = false
+}
+
+// CheckRate returns an error if the rate is out of MinRate and MaxRate
+func CheckRate(rate float64) error {
+	if rate < MinRate || rate > MaxRate {
+		return fmt.Errorf("recordings: the rate %v is out of range [%v, %v]", rate, MinRate, MaxRate)
+	}
+	return nil
+}
+
+// Trajectory returns the Trajectory replaying the Recording from the start position at the rate,
+// moving to the first frame within LeadIn
+// Every frame holds the previous pose until its time, then moves over its delta, cut short by the next frame
+func (r *Recording) Trajectory(start armlink.JointPosition, rate float64) *trajectory.Trajectory {
+	scale := func(d time.Duration) time.Duration {
+		return time.Duration(float64(d) / rate)
+	}
+	t := &trajectory.Trajectory{
+		Start:         start,
+		Interpolation: trajectory.Linear,
+	}
+	if len(r.Frames) == 0 {
+		return t
+	}
+	t.Waypoints = append(t.Waypoints, trajectory.Waypoint{
+		Position: r.Frames[0].JointPosition(),
+		Duration: LeadIn,
+	})
+	// the time reached by the Waypoints, after the LeadIn
+	var end time.Duration
+	for i, f := range r.Frames[1:] {
+		at := scale(time.Duration(f.At) * time.Millisecond)
+		if at > end {
+			t.Waypoints = append(t.Waypoints, trajectory.Waypoint{
+				Position: r.Frames[i].JointPosition(),
+				Duration: at - end,
+			})
+			end = at
+		}
+		move := scale(time.Duration(f.Delta) * frameDelta)
+		if i+2 < len(r.Frames) {
+			if next := scale(time.Duration(r.Frames[i+2].At) * time.Millisecond); end+move > next {
+				move = next - end
+			}
+		}
+		if move < time.Millisecond {
+			move = time.Millisecond
+		}
+		t.Waypoints = append(t.Waypoints, trajectory.Waypoint{
+			Position: f.JointPosition(),
+			Duration: move,
+		})
+		end += move
+	}
+	// keep the last pose until the end of the Recording
+	if d := scale(time.Duration(r.Duration) * time.Millisecond); d > end {
+		t.Waypoints = append(t.Waypoints, trajectory.Waypoint{
+			Position: r.Frames[len(r.Frames)-1].JointPosition(),
+			Duration: d - end,
+		})
+	}
+	return t
+}
+
+// CheckName returns an error if the name is not valid for a Recording
+func CheckName(name string) error {
+	if name == "" || len(name) > 64 {
+		return fmt.Errorf("recordings: invalid name %q, from 1 to 64 characters", name)
+	}
+	return nil
+}
+
+// Store is the Recordings saved in a directory, named by their IDs
+type Store struct {
+	dir        string
+	mutex      sync.Mutex
+	recordings map[string]*Recording
+	last       int
+}
+
+// Open reads the Store from the directory, empty if it does not exist yet
+func Open(dir string) (*Store, error) {
+	s := &Store{
+		dir:        dir,
+		recordings: map[string]*Recording{},
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		r := &Recording{}
+		if err := json.Unmarshal(b, r); err != nil {
+			return nil, fmt.Errorf("recordings: invalid file %v: %v", file, err)
+		}
+		for i, f := range r.Frames {
+			if err := f.JointPosition().Validate(); err != nil {
+				return nil, fmt.Errorf("recordings: invalid frame %v in %v: %v", i, file, err)
+			}
+		}
+		if n, err := strconv.Atoi(r.ID); err == nil && n > s.last {
+			s.last = n
+		}
+		s.recordings[r.ID] = r
+	}
+	return s, nil
+}
+
+// NextID reserves the ID of a new Recording
+func (s *Store) NextID() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.last++
+	return strconv.Itoa(s.last)
+}
+
+// Get returns the Recording by its ID
+func (s *Store) Get(id string) (*Recording, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	r, ok := s.recordings[id]
+	return r, ok
+}
+
+// List returns the Info of the Recordings, the oldest first
+func (s *Store) List() []Info {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := []Info{}
+	for _, r := range s.recordings {
+		list = append(list, r.Info)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+	return list
+}
+
+// Save writes the Recording to its file
+func (s *Store) Save(r *Recording) error {
+	if err := CheckName(r.Name); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.write(r); err != nil {
+		return err
+	}
+	s.recordings[r.ID] = r
+	return nil
+}
+
+// Rename renames the Recording by its ID, and returns its Info
+func (s *Store) Rename(id, name string) (Info, error) {
+	if err := CheckName(name); err != nil {
+		return Info{}, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	r, ok := s.recordings[id]
+	if !ok {
+		return Info{}, fmt.Errorf("recordings: no recording %q", id)
+	}
+	renamed := *r
+	renamed.Name = name
+	if err := s.write(&renamed); err != nil {
+		return Info{}, err
+	}
+	s.recordings[id] = &renamed
+	return renamed.Info, nil
+}
+
+// write writes the Recording to its file, through a temporary one not to lose it halfway
+func (s *Store) write(r *Recording) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(s.dir, r.ID+".json")
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
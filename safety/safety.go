@@ -0,0 +1,164 @@
+// Package safety keeps the gripper within the allowed workspace of the arm and out of the keep-out zones,
+// checking the positions computed by the kinematics of leubot before the poses are sent.
+package safety
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+	"github.com/Interactions-HSG/leubot/kinematics"
+	"github.com/Interactions-HSG/leubot/trajectory"
+)
+
+// Point is a point of the workspace in millimeters, in the frame of kinematics.Position
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Box is the box between the corners Min and Max
+type Box struct {
+	Min Point `json:"min"`
+	Max Point `json:"max"`
+}
+
+// Cylinder is the upright cylinder around X and Y from MinZ to MaxZ
+type Cylinder struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Radius float64 `json:"radius"`
+	MinZ   float64 `json:"minZ"`
+	MaxZ   float64 `json:"maxZ"`
+}
+
+// Zone is a named Box or Cylinder
+type Zone struct {
+	Name     string    `json:"name"`
+	Box      *Box      `json:"box,omitempty"`
+	Cylinder *Cylinder `json:"cylinder,omitempty"`
+}
+
+// Contains reports if the Position is within the Zone, on its surface included
+func (z Zone) Contains(p kinematics.Position) bool {
+	if b := z.Box; b != nil {
+		return p.X >= b.Min.X && p.X <= b.Max.X &&
+			p.Y >= b.Min.Y && p.Y <= b.Max.Y &&
+			p.Z >= b.Min.Z && p.Z <= b.Max.Z
+	}
+	c := z.Cylinder
+	dx, dy := p.X-c.X, p.Y-c.Y
+	return dx*dx+dy*dy <= c.Radius*c.Radius && p.Z >= c.MinZ && p.Z <= c.MaxZ
+}
+
+// validate returns an error unless the Zone has exactly one well-formed shape
+func (z Zone) validate() error {
+	switch {
+	case (z.Box == nil) == (z.Cylinder == nil):
+		return fmt.Errorf("safety: zone %q needs either a box or a cylinder", z.Name)
+	case z.Box != nil && (z.Box.Min.X > z.Box.Max.X || z.Box.Min.Y > z.Box.Max.Y || z.Box.Min.Z > z.Box.Max.Z):
+		return fmt.Errorf("safety: zone %q has a box with its min beyond its max", z.Name)
+	case z.Cylinder != nil && (z.Cylinder.Radius <= 0 || z.Cylinder.MinZ > z.Cylinder.MaxZ):
+		return fmt.Errorf("safety: zone %q has a cylinder with no volume", z.Name)
+	}
+	return nil
+}
+
+// Envelope is where the gripper may go, within the Allowed zone if any and out of all the KeepOut zones
+type Envelope struct {
+	Allowed *Zone  `json:"allowed,omitempty"`
+	KeepOut []Zone `json:"keepOut,omitempty"`
+}
+
+// Load reads the Envelope from the JSON file at the path
+func Load(path string) (*Envelope, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	e := &Envelope{}
+	if err := json.Unmarshal(b, e); err != nil {
+		return nil, fmt.Errorf("safety: invalid file %v: %v", path, err)
+	}
+	if e.Allowed != nil {
+		if e.Allowed.Name == "" {
+			e.Allowed.Name = "allowed"
+		}
+		if err := e.Allowed.validate(); err != nil {
+			return nil, err
+		}
+	}
+	for i := range e.KeepOut {
+		if e.KeepOut[i].Name == "" {
+			e.KeepOut[i].Name = fmt.Sprintf("keep-out %v", i)
+		}
+		if err := e.KeepOut[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Violation is the constraint of the Envelope broken by the gripper at the Position,
+// At the time along a trajectory if the pose is one of its frames
+type Violation struct {
+	Zone     string
+	KeepOut  bool
+	Position kinematics.Position
+	At       *time.Duration
+}
+
+func (v *Violation) Error() string {
+	where := "outside of the allowed zone"
+	if v.KeepOut {
+		where = "inside of the keep-out zone"
+	}
+	s := fmt.Sprintf("safety: the gripper at (%.0f, %.0f, %.0f) mm is %v %q", v.Position.X, v.Position.Y, v.Position.Z, where, v.Zone)
+	if v.At != nil {
+		s += fmt.Sprintf(" at %v along the trajectory", *v.At)
+	}
+	return s
+}
+
+// Check returns a Violation if the Position is not within the Envelope
+func (e *Envelope) Check(p kinematics.Position) error {
+	if e.Allowed != nil && !e.Allowed.Contains(p) {
+		return &Violation{Zone: e.Allowed.Name, Position: p}
+	}
+	for _, z := range e.KeepOut {
+		if z.Contains(p) {
+			return &Violation{Zone: z.Name, KeepOut: true, Position: p}
+		}
+	}
+	return nil
+}
+
+// CheckTrajectory returns the first Violation of the frames of the Trajectory streamed every period,
+// with the positions of the gripper given by the arm
+func (e *Envelope) CheckTrajectory(t *trajectory.Trajectory, period time.Duration, arm kinematics.Reactor) error {
+	check := func(elapsed time.Duration) error {
+		jp, _ := t.At(elapsed)
+		if err := e.Check(arm.Forward(jp)); err != nil {
+			v := err.(*Violation)
+			v.At = &elapsed
+			return v
+		}
+		return nil
+	}
+	// the first frame is sent a period after the start, where the arm already is
+	d := t.Duration()
+	for elapsed := period; elapsed < d; elapsed += period {
+		if err := check(elapsed); err != nil {
+			return err
+		}
+	}
+	return check(d)
+}
+
+// CheckPose returns a Violation if the gripper of the arm in the JointPosition is not within the Envelope
+func (e *Envelope) CheckPose(jp armlink.JointPosition, arm kinematics.Reactor) error {
+	return e.Check(arm.Forward(jp))
+}
@@ -0,0 +1,166 @@
+package safety
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+	"github.com/Interactions-HSG/leubot/kinematics"
+	"github.com/Interactions-HSG/leubot/trajectory"
+	"github.com/Interactions-HSG/leubot/units"
+)
+
+var arm = kinematics.Reactor{
+	Geometry:    kinematics.ReactorGeometry,
+	Calibration: units.DefaultCalibration,
+}
+
+var (
+	box = Zone{Name: "box", Box: &Box{
+		Min: Point{X: -100, Y: -100, Z: 0},
+		Max: Point{X: 100, Y: 100, Z: 200},
+	}}
+	cylinder = Zone{Name: "cylinder", Cylinder: &Cylinder{X: 50, Y: 50, Radius: 10, MinZ: 0, MaxZ: 100}}
+)
+
+func TestZoneContains(t *testing.T) {
+	tests := []struct {
+		name string
+		zone Zone
+		p    kinematics.Position
+		want bool
+	}{
+		{"box inside", box, kinematics.Position{X: 0, Y: 0, Z: 100}, true},
+		{"box on the surface", box, kinematics.Position{X: 100, Y: -100, Z: 200}, true},
+		{"box beyond x", box, kinematics.Position{X: 101, Y: 0, Z: 100}, false},
+		{"box below", box, kinematics.Position{X: 0, Y: 0, Z: -1}, false},
+		{"cylinder on the axis", cylinder, kinematics.Position{X: 50, Y: 50, Z: 50}, true},
+		{"cylinder on the side", cylinder, kinematics.Position{X: 60, Y: 50, Z: 50}, true},
+		{"cylinder beyond the radius", cylinder, kinematics.Position{X: 58, Y: 58, Z: 50}, false},
+		{"cylinder above", cylinder, kinematics.Position{X: 50, Y: 50, Z: 101}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.zone.Contains(tt.p); got != tt.want {
+			t.Errorf("%v: Contains(%v) = %v, want %v", tt.name, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestEnvelopeCheck(t *testing.T) {
+	e := &Envelope{Allowed: &box, KeepOut: []Zone{cylinder}}
+	tests := []struct {
+		name    string
+		p       kinematics.Position
+		zone    string
+		keepOut bool
+	}{
+		{"allowed", kinematics.Position{X: 0, Y: 0, Z: 100}, "", false},
+		{"outside of the allowed zone", kinematics.Position{X: 0, Y: 0, Z: 300}, "box", false},
+		{"inside of a keep-out zone", kinematics.Position{X: 50, Y: 50, Z: 50}, "cylinder", true},
+	}
+	for _, tt := range tests {
+		err := e.Check(tt.p)
+		if tt.zone == "" {
+			if err != nil {
+				t.Errorf("%v: Check(%v) = %v, want nil", tt.name, tt.p, err)
+			}
+			continue
+		}
+		v, ok := err.(*Violation)
+		if !ok {
+			t.Errorf("%v: Check(%v) = %v, want a Violation", tt.name, tt.p, err)
+			continue
+		}
+		if v.Zone != tt.zone || v.KeepOut != tt.keepOut || v.At != nil {
+			t.Errorf("%v: Check(%v) = %+v, want the zone %q with KeepOut %v", tt.name, tt.p, v, tt.zone, tt.keepOut)
+		}
+	}
+}
+
+func TestCheckTrajectory(t *testing.T) {
+	home := armlink.HomePosition()
+	// the base turns the gripper from the front of the arm to its side
+	side := home
+	side.Base = 0
+	front := &Zone{Name: "front", Box: &Box{
+		Min: Point{X: -400, Y: 0, Z: 0},
+		Max: Point{X: 400, Y: 400, Z: 400},
+	}}
+	period := 20 * time.Millisecond
+	tests := []struct {
+		name       string
+		envelope   *Envelope
+		trajectory *trajectory.Trajectory
+		violated   bool
+	}{
+		{"staying in front", &Envelope{Allowed: front}, &trajectory.Trajectory{
+			Start:     home,
+			Waypoints: []trajectory.Waypoint{{Position: home, Duration: time.Second}},
+		}, false},
+		{"turning to the side", &Envelope{Allowed: front}, &trajectory.Trajectory{
+			Start:     home,
+			Waypoints: []trajectory.Waypoint{{Position: side, Duration: time.Second}},
+		}, true},
+		{"no zone", &Envelope{}, &trajectory.Trajectory{
+			Start:     home,
+			Waypoints: []trajectory.Waypoint{{Position: side, Duration: time.Second}},
+		}, false},
+	}
+	for _, tt := range tests {
+		err := tt.envelope.CheckTrajectory(tt.trajectory, period, arm)
+		if !tt.violated {
+			if err != nil {
+				t.Errorf("%v: CheckTrajectory() = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		v, ok := err.(*Violation)
+		if !ok || v.At == nil {
+			t.Errorf("%v: CheckTrajectory() = %v, want a Violation along the trajectory", tt.name, err)
+			continue
+		}
+		if *v.At <= 0 || *v.At > tt.trajectory.Duration() || *v.At%period != 0 {
+			t.Errorf("%v: violated at %v, want a frame within %v", tt.name, *v.At, tt.trajectory.Duration())
+		}
+		if err := tt.envelope.CheckPose(tt.trajectory.Waypoints[0].Position, arm); err == nil {
+			t.Errorf("%v: CheckPose() of the last frame = nil, want a Violation", tt.name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "safety")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"box and cylinder", `{"allowed": {"box": {"min": {"x": -1, "y": -1, "z": 0}, "max": {"x": 1, "y": 1, "z": 1}}}, "keepOut": [{"cylinder": {"radius": 1, "maxZ": 1}}]}`, false},
+		{"no shape", `{"allowed": {"name": "table"}}`, true},
+		{"both shapes", `{"keepOut": [{"box": {}, "cylinder": {"radius": 1}}]}`, true},
+		{"min beyond max", `{"allowed": {"box": {"min": {"x": 1}, "max": {"x": -1}}}}`, true},
+		{"flat cylinder", `{"keepOut": [{"cylinder": {"radius": 0, "maxZ": 1}}]}`, true},
+		{"not JSON", `{`, true},
+	}
+	for i, tt := range tests {
+		path := filepath.Join(dir, string(rune('a'+i))+".json")
+		if err := ioutil.WriteFile(path, []byte(tt.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		e, err := Load(path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: Load() error = %v, want an error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (e.Allowed.Name != "allowed" || e.KeepOut[0].Name != "keep-out 0") {
+			t.Errorf("%v: Load() named the zones %q and %q", tt.name, e.Allowed.Name, e.KeepOut[0].Name)
+		}
+	}
+}
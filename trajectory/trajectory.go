@@ -0,0 +1,98 @@
+// Package trajectory interpolates the joints of the arm between waypoints,
+// so that the arm can be streamed small steps instead of jumping to a far pose.
+package trajectory
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Interactions-HSG/leubot/armlink"
+)
+
+// Interpolation shapes the motion between two waypoints
+type Interpolation int
+
+const (
+	// MinimumJerk starts and stops smoothly at every waypoint
+	MinimumJerk Interpolation = iota
+	// Linear moves at a constant speed between the waypoints
+	Linear
+)
+
+// ParseInterpolation returns the Interpolation by its name, MinimumJerk if empty
+func ParseInterpolation(s string) (Interpolation, error) {
+	switch s {
+	case "", "minjerk":
+		return MinimumJerk, nil
+	case "linear":
+		return Linear, nil
+	}
+	return MinimumJerk, fmt.Errorf("trajectory: unknown interpolation %q, one of minjerk or linear", s)
+}
+
+func (i Interpolation) String() string {
+	if i == Linear {
+		return "linear"
+	}
+	return "minjerk"
+}
+
+// progress returns the fraction of the way covered at the fraction s of the time
+func (i Interpolation) progress(s float64) float64 {
+	if i == Linear {
+		return s
+	}
+	return s * s * s * (10 - 15*s + 6*s*s)
+}
+
+// Waypoint is a JointPosition reached Duration after the previous one
+type Waypoint struct {
+	Position armlink.JointPosition
+	Duration time.Duration
+}
+
+// Trajectory goes through the Waypoints from the Start
+type Trajectory struct {
+	Start         armlink.JointPosition
+	Waypoints     []Waypoint
+	Interpolation Interpolation
+}
+
+// Duration returns the time to go through all the Waypoints
+func (t *Trajectory) Duration() time.Duration {
+	var d time.Duration
+	for _, w := range t.Waypoints {
+		d += w.Duration
+	}
+	return d
+}
+
+// At returns the JointPosition at the elapsed time since the start,
+// and the index of the Waypoint being approached, len(Waypoints) once the last one is reached
+func (t *Trajectory) At(elapsed time.Duration) (armlink.JointPosition, int) {
+	from := t.Start
+	for i, w := range t.Waypoints {
+		if elapsed < w.Duration {
+			return interpolate(from, w.Position, t.Interpolation.progress(float64(elapsed)/float64(w.Duration))), i
+		}
+		elapsed -= w.Duration
+		from = w.Position
+	}
+	return from, len(t.Waypoints)
+}
+
+// interpolate returns the JointPosition at the fraction p of the way from a to b
+func interpolate(a, b armlink.JointPosition, p float64) armlink.JointPosition {
+	between := func(a, b uint16) uint16 {
+		return uint16(math.Round(float64(a) + (float64(b)-float64(a))*p))
+	}
+	return armlink.JointPosition{
+		Base:          between(a.Base, b.Base),
+		Shoulder:      between(a.Shoulder, b.Shoulder),
+		Elbow:         between(a.Elbow, b.Elbow),
+		WristAngle:    between(a.WristAngle, b.WristAngle),
+		WristRotation: between(a.WristRotation, b.WristRotation),
+		Gripper:       between(a.Gripper, b.Gripper),
+	}
+}
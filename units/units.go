@@ -0,0 +1,83 @@
+// Package units converts the joint values of the arm between the raw AX-12 ticks and angles.
+package units
+
+import (
+	"fmt"
+	"math"
+)
+
+// Unit is a unit of the joint values
+type Unit string
+
+// Units of the joint values
+const (
+	Ticks   Unit = "ticks"
+	Degrees Unit = "deg"
+	Radians Unit = "rad"
+)
+
+// DegreesPerTick is the resolution of the AX-12, 300° over 1024 ticks
+const DegreesPerTick = 300.0 / 1024
+
+// ParseUnit returns the Unit by its name, Ticks if empty
+func ParseUnit(s string) (Unit, error) {
+	switch s {
+	case "", "ticks":
+		return Ticks, nil
+	case "deg", "degrees":
+		return Degrees, nil
+	case "rad", "radians":
+		return Radians, nil
+	}
+	return "", fmt.Errorf("units: unknown unit %q, one of ticks, deg or rad", s)
+}
+
+// perTick returns the size of a tick in the Unit
+func (u Unit) perTick() float64 {
+	switch u {
+	case Degrees:
+		return DegreesPerTick
+	case Radians:
+		return DegreesPerTick * math.Pi / 180
+	}
+	return 1
+}
+
+// Joint is the calibration of a joint, Zero is the ticks at the angle 0
+// and a Reverse joint turns towards the smaller ticks for the positive angles
+type Joint struct {
+	Zero    float64 `json:"zero"`
+	Reverse bool    `json:"reverse"`
+}
+
+// direction returns the sign of the angles per tick
+func (j Joint) direction() float64 {
+	if j.Reverse {
+		return -1
+	}
+	return 1
+}
+
+// FromTicks returns the value of the ticks in the Unit
+func (j Joint) FromTicks(ticks int, u Unit) float64 {
+	if u == Ticks {
+		return float64(ticks)
+	}
+	return (float64(ticks) - j.Zero) * j.direction() * u.perTick()
+}
+
+// ToTicks returns the ticks nearest to the value in the Unit
+func (j Joint) ToTicks(v float64, u Unit) int {
+	if u == Ticks {
+		return int(math.Round(v))
+	}
+	return int(math.Round(v/u.perTick()*j.direction() + j.Zero))
+}
+
+// DeltaTicks returns the number of ticks nearest to the relative move in the Unit
+func (j Joint) DeltaTicks(v float64, u Unit) int {
+	if u == Ticks {
+		return int(math.Round(v))
+	}
+	return int(math.Round(v / u.perTick() * j.direction()))
+}
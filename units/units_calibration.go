@@ -0,0 +1,47 @@
+package units
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Calibration is the Joint of each joint by its name
+type Calibration map[string]Joint
+
+// DefaultCalibration puts the angle 0 in the middle of the servos, and the gripper closed
+var DefaultCalibration = Calibration{
+	"base":          {Zero: 512},
+	"shoulder":      {Zero: 512},
+	"elbow":         {Zero: 512},
+	"wristAngle":    {Zero: 512},
+	"wristRotation": {Zero: 512},
+	"gripper":       {Zero: 0},
+}
+
+// LoadCalibration reads the calibration of the joints from a JSON file such as
+//
+//	{"shoulder": {"zero": 205, "reverse": true}}
+//
+// The joints missing from the file keep the DefaultCalibration.
+func LoadCalibration(path string) (Calibration, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var joints map[string]Joint
+	if err := json.Unmarshal(b, &joints); err != nil {
+		return nil, fmt.Errorf("units: invalid calibration %v: %v", path, err)
+	}
+	c := Calibration{}
+	for name, j := range DefaultCalibration {
+		c[name] = j
+	}
+	for name, j := range joints {
+		if _, ok := c[name]; !ok {
+			return nil, fmt.Errorf("units: invalid calibration %v: unknown joint %q", path, name)
+		}
+		c[name] = j
+	}
+	return c, nil
+}